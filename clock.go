@@ -0,0 +1,181 @@
+package goid
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TimeSource supplies the wall time a generator derives its timestamps from.
+// A nil TimeSource means time.Now.
+type TimeSource interface {
+	Now() time.Time
+}
+
+type TimeSourceFunc func() time.Time
+
+func (f TimeSourceFunc) Now() time.Time {
+	return f()
+}
+
+// SetTimeSource sets the time source of the package level generators.
+func SetTimeSource(ts TimeSource) {
+	_id.SetTimeSource(ts)
+	_id2.SetTimeSource(ts)
+	_id3.SetTimeSource(ts)
+	_hlc.SetTimeSource(ts)
+}
+
+// NTPClock is a TimeSource that adds an NTP offset, measured periodically in
+// the background, to the local clock, so that hosts with drifting clocks
+// still mint IDs aligned with the fleet.
+type NTPClock struct {
+	client     *NTPClient
+	interval   time.Duration
+	maxStep    time.Duration
+	offset     atomic.Int64
+	errHandler func(error)
+	mu         sync.Mutex
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+func NewNTPClock(client *NTPClient, interval time.Duration) *NTPClock {
+	if interval <= 0 {
+		panic("invalid ntp clock interval")
+	}
+	return &NTPClock{
+		client:   client,
+		interval: interval,
+	}
+}
+
+// SetMaxStep limits how far a single measurement may move the applied offset,
+// so that a large correction is slewed over several intervals instead of
+// stepping the clock; 0 applies every measurement at once.
+func (c *NTPClock) SetMaxStep(d time.Duration) {
+	if d < 0 {
+		panic("invalid ntp clock max step")
+	}
+	c.maxStep = d
+}
+
+func (c *NTPClock) GetMaxStep() time.Duration {
+	return c.maxStep
+}
+
+// SetErrorHandler sets the function called when a background measurement
+// fails; the previous offset stays in use.
+func (c *NTPClock) SetErrorHandler(f func(error)) {
+	c.errHandler = f
+}
+
+// Start measures the offset once, failing if that is not possible, and then
+// keeps measuring it every interval until Stop is called.
+func (c *NTPClock) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		return nil
+	}
+	offset, err := c.client.Measure()
+	if err != nil {
+		return err
+	}
+	c.offset.Store(int64(offset))
+	c.stop, c.done = make(chan struct{}), make(chan struct{})
+	go c.run(c.stop, c.done)
+	return nil
+}
+
+func (c *NTPClock) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop == nil {
+		return
+	}
+	close(c.stop)
+	<-c.done
+	c.stop, c.done = nil, nil
+}
+
+func (c *NTPClock) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.update()
+		}
+	}
+}
+
+func (c *NTPClock) update() {
+	offset, err := c.client.Measure()
+	if err != nil {
+		if c.errHandler != nil {
+			c.errHandler(err)
+		}
+		return
+	}
+	if c.maxStep > 0 {
+		cur := c.Offset()
+		if offset > cur+c.maxStep {
+			offset = cur + c.maxStep
+		} else if offset < cur-c.maxStep {
+			offset = cur - c.maxStep
+		}
+	}
+	c.offset.Store(int64(offset))
+}
+
+func (c *NTPClock) Offset() time.Duration {
+	return time.Duration(c.offset.Load())
+}
+
+func (c *NTPClock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+type monotonicAnchor struct {
+	wall time.Time
+	mono time.Time
+}
+
+// MonotonicClock is a TimeSource that reads the wall clock once, when it is
+// anchored, and derives every later time from Go's monotonic clock. Wall
+// clock steps (manual changes, NTP steps) therefore no longer reach the
+// generators and cannot cause backtracks within the process lifetime.
+type MonotonicClock struct {
+	anchor atomic.Pointer[monotonicAnchor]
+}
+
+func NewMonotonicClock() *MonotonicClock {
+	m := &MonotonicClock{}
+	m.Anchor(time.Now())
+	return m
+}
+
+// Anchor makes the current instant correspond to the wall time t. Anchoring
+// backwards is handled by the generators like any other clock backtrack.
+func (m *MonotonicClock) Anchor(t time.Time) {
+	m.anchor.Store(&monotonicAnchor{wall: t.Round(0), mono: time.Now()})
+}
+
+// AnchorNTP anchors the clock to the time measured through c.
+func (m *MonotonicClock) AnchorNTP(c *NTPClient) error {
+	offset, err := c.Measure()
+	if err != nil {
+		return err
+	}
+	m.Anchor(time.Now().Add(offset))
+	return nil
+}
+
+func (m *MonotonicClock) Now() time.Time {
+	a := m.anchor.Load()
+	return a.wall.Add(time.Since(a.mono))
+}
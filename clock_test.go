@@ -0,0 +1,109 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeSource(t *testing.T) {
+	future := time.Date(2100, 1, 2, 3, 4, 5, 0, time.UTC)
+	ts := TimeSourceFunc(func() time.Time { return future })
+
+	id := NewID2()
+	id.SetTimeSource(ts)
+	if tsV, _ := ResolveID2(id.Generate(), id); tsV != future.Unix() {
+		t.Errorf("ID2 timestamp = %d, want %d", tsV, future.Unix())
+	}
+	id3 := NewID3()
+	id3.SetTimeSource(ts)
+	if tsV, _ := ResolveID3(id3.Generate(), id3); tsV != future.UnixMilli() {
+		t.Errorf("ID3 timestamp = %d, want %d", tsV, future.UnixMilli())
+	}
+	hlc := NewHLC()
+	hlc.SetTimeSource(ts)
+	if tsV, _ := ResolveHLC(hlc.Generate(), hlc); tsV != future.UnixMilli() {
+		t.Errorf("HLC timestamp = %d, want %d", tsV, future.UnixMilli())
+	}
+}
+
+func TestNTPClock(t *testing.T) {
+	s := newFakeNTPServer(t, time.Hour)
+	c := NewNTPClock(NewNTPClient(s.Addr()), 10*time.Millisecond)
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	assertOffset(t, c.Offset(), time.Hour)
+
+	id := NewID()
+	id.SetTimeSource(c)
+	tsV, _ := ResolveID(id.Generate(), id)
+	if d := tsV - time.Now().Add(time.Hour).Unix(); d < -1 || d > 1 {
+		t.Errorf("timestamp is %ds away from NTP time", d)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if q := s.queries.Load(); q < 2 {
+		t.Errorf("offset was not measured in background, %d queries", q)
+	}
+}
+
+func TestNTPClock_MaxStep(t *testing.T) {
+	s := newFakeNTPServer(t, time.Minute)
+	c := NewNTPClock(NewNTPClient(s.Addr()), time.Hour)
+	c.SetMaxStep(time.Second)
+	c.update()
+	assertOffset(t, c.Offset(), time.Second)
+	c.update()
+	assertOffset(t, c.Offset(), 2*time.Second)
+}
+
+func TestNTPClock_Error(t *testing.T) {
+	c := NewNTPClock(NewNTPClient(), time.Hour)
+	if err := c.Start(); !errors.Is(err, ErrNTPQuorum) {
+		t.Errorf("Start() error = %v, want %v", err, ErrNTPQuorum)
+	}
+	var got error
+	c.SetErrorHandler(func(err error) { got = err })
+	c.update()
+	if !errors.Is(got, ErrNTPQuorum) || c.Offset() != 0 {
+		t.Errorf("error handler got %v, offset %v", got, c.Offset())
+	}
+}
+
+func TestMonotonicClock(t *testing.T) {
+	m := NewMonotonicClock()
+	if d := time.Since(m.Now()); d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("Now() is %v away from time.Now()", d)
+	}
+
+	past := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.Anchor(past)
+	time.Sleep(10 * time.Millisecond)
+	now := m.Now()
+	if d := now.Sub(past); d < 10*time.Millisecond || d > time.Second {
+		t.Errorf("Now() = %v, want %v plus elapsed time", now, past)
+	}
+	if m.Now().Before(now) {
+		t.Errorf("Now() went backwards")
+	}
+
+	id := NewID3()
+	id.SetTimeSource(m)
+	if tsV, _ := ResolveID3(id.Generate(), id); tsV < past.UnixMilli() || tsV > past.Add(time.Second).UnixMilli() {
+		t.Errorf("timestamp %d not derived from anchor %d", tsV, past.UnixMilli())
+	}
+}
+
+func TestMonotonicClock_AnchorNTP(t *testing.T) {
+	s := newFakeNTPServer(t, -time.Hour)
+	m := NewMonotonicClock()
+	if err := m.AnchorNTP(NewNTPClient(s.Addr())); err != nil {
+		t.Fatal(err)
+	}
+	assertOffset(t, time.Until(m.Now()), -time.Hour)
+	if err := m.AnchorNTP(NewNTPClient()); !errors.Is(err, ErrNTPQuorum) {
+		t.Errorf("AnchorNTP() error = %v, want %v", err, ErrNTPQuorum)
+	}
+}
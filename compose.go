@@ -0,0 +1,33 @@
+package goid
+
+import "time"
+
+// Compose builds the ID that the layout would give to seq generated by node
+// in the tick of t, with the layout's tag, on shard 0; it is the inverse of
+// Decompose for IDs from Generate.
+func (l Layout) Compose(t time.Time, node, seq uint32) (int64, error) {
+	ticks := timeTicks(t, l.Tick)
+	if ticks < 0 || ticks > int64(1)<<l.TimeBits-1 {
+		return 0, ErrTimestampOverflow
+	}
+	if node > 1<<l.NodeBits-1 {
+		return 0, ErrNodeOutOfRange
+	}
+	if seq > 1<<l.SeqBits-1 {
+		return 0, ErrSeqOutOfRange
+	}
+	low := l.ShardBits + l.NodeBits + l.SeqBits
+	return ticks<<(l.TagBits+low) | int64(l.Tag)<<low | int64(node)<<l.SeqBits | int64(seq), nil
+}
+
+func (i *ID) Compose(t time.Time, node, seq uint32) (int64, error) {
+	return i.Layout().Compose(t, node, seq)
+}
+
+func (i *ID2) Compose(t time.Time, node, seq uint32) (int64, error) {
+	return i.Layout().Compose(t, node, seq)
+}
+
+func (i *ID3) Compose(t time.Time, node, seq uint32) (int64, error) {
+	return i.Layout().Compose(t, node, seq)
+}
@@ -0,0 +1,79 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestCompose_RoundTrip(t *testing.T) {
+	id3 := NewID3()
+	id3.SetBits(43)
+	id3.SetNode(0, 4)
+	for _, l := range []Layout{NewID().Layout(), NewID2().Layout(), NewID3().Layout(), id3.Layout(),
+		{Name: "id", TimeBits: 32, NodeBits: 10, SeqBits: 11, Tick: time.Second}} {
+		l := l
+		f := func(ticks int64, node, seq uint32) bool {
+			ticks &= 1<<l.TimeBits - 1
+			node &= 1<<l.NodeBits - 1
+			seq &= 1<<l.SeqBits - 1
+			ts := tickTime(ticks, l.Tick)
+			idV, err := l.Compose(ts, node, seq)
+			if err != nil || idV < 0 || idV >= 1<<MaxBits {
+				return false
+			}
+			p := l.decompose(idV)
+			return p.Timestamp == ticks && p.Time.Equal(ts) && p.Node == node && p.Sequence == seq
+		}
+		if err := quick.Check(f, &quick.Config{MaxCount: 10000}); err != nil {
+			t.Errorf("%s: %v", l.Name, err)
+		}
+	}
+}
+
+func TestCompose_Generated(t *testing.T) {
+	id := NewID2()
+	id.SetNode(7, 5)
+	for i := 0; i < 1000; i++ {
+		idV := id.Generate()
+		p := id.Decompose(idV)
+		got, err := id.Compose(p.Time, p.Node, p.Sequence)
+		if err != nil || got != idV {
+			t.Fatalf("Compose(Decompose(%d)) = %d, %v", idV, got, err)
+		}
+		ts, c := ResolveID2(idV, id)
+		if ts != p.Timestamp || c != p.Sequence {
+			t.Fatalf("ResolveID2(%d) = %d, %d, want %d, %d", idV, ts, c, p.Timestamp, p.Sequence)
+		}
+	}
+}
+
+func TestCompose_Bounds(t *testing.T) {
+	id := NewID3()
+	id.SetNode(1, 4)
+	now := time.Now()
+	tests := []struct {
+		name    string
+		t       time.Time
+		node    uint32
+		seq     uint32
+		wantErr error
+	}{
+		{"valid", now, 15, 127, nil},
+		{"before epoch", time.Unix(-1, 0), 1, 1, ErrTimestampOverflow},
+		{"after max time", time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC), 1, 1, ErrTimestampOverflow},
+		{"node", now, 16, 1, ErrNodeOutOfRange},
+		{"sequence", now, 1, 128, ErrSeqOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := id.Compose(tt.t, tt.node, tt.seq); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compose() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := NewID().Compose(now, 1, 1); !errors.Is(err, ErrNodeOutOfRange) {
+		t.Errorf("Compose() without node bits error = %v, want %v", err, ErrNodeOutOfRange)
+	}
+}
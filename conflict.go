@@ -0,0 +1,273 @@
+package goid
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultConflictInterval = 5 * time.Second
+	conflictMagic           = "goid1"
+)
+
+// Halter stops a generator, as ConflictDetector and NodeRegistry do when its
+// node turns out to be used by another instance.
+type Halter interface {
+	Halt(err error)
+	Resume()
+}
+
+var (
+	_ Halter = (*ID)(nil)
+	_ Halter = (*ID2)(nil)
+	_ Halter = (*ID3)(nil)
+)
+
+// Halt makes TryGenerate return err, and Generate panic with it, until Resume
+// is called.
+func (h *hooks) Halt(err error) {
+	if err == nil {
+		panic("nil halt error")
+	}
+	h.halted.Store(&err)
+}
+
+func (h *hooks) Resume() {
+	h.halted.Store(nil)
+}
+
+func (h *hooks) haltErr() error {
+	if err := h.halted.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// Conflict describes an announcement of another instance using our node.
+type Conflict struct {
+	Node     uint32
+	NodeBits uint8
+	Instance string
+	Peer     net.Addr
+}
+
+// ConflictDetector periodically announces the node of a generator together
+// with a random instance id over UDP, to a peer list or a multicast or
+// broadcast address, and listens for the announcements of other instances.
+// An announcement of the same node by another instance is a conflict.
+type ConflictDetector struct {
+	gen        Generator
+	instance   string
+	conn       *net.UDPConn
+	mu         sync.Mutex
+	peers      []*net.UDPAddr
+	interval   time.Duration
+	onConflict func(Conflict)
+	halt       bool
+	stop       chan struct{}
+	stopped    bool
+	wg         sync.WaitGroup
+}
+
+// NewConflictDetector listens on the UDP address listen, joining the group if
+// it is a multicast address, and announces to peers, which may include
+// multicast and broadcast addresses.
+func NewConflictDetector(g Generator, listen string, peers ...string) (*ConflictDetector, error) {
+	laddr, err := net.ResolveUDPAddr("udp", listen)
+	if err != nil {
+		return nil, err
+	}
+	var conn *net.UDPConn
+	if laddr.IP.IsMulticast() {
+		conn, err = net.ListenMulticastUDP("udp", nil, laddr)
+	} else {
+		conn, err = net.ListenUDP("udp", laddr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	d := &ConflictDetector{
+		gen:      g,
+		instance: newInstanceID(),
+		conn:     conn,
+		interval: DefaultConflictInterval,
+	}
+	if err = d.SetPeers(peers...); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+func newInstanceID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (d *ConflictDetector) SetPeers(peers ...string) error {
+	addrs := make([]*net.UDPAddr, 0, len(peers))
+	for _, peer := range peers {
+		addr, err := net.ResolveUDPAddr("udp", peer)
+		if err != nil {
+			return err
+		}
+		addrs = append(addrs, addr)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.peers = addrs
+	return nil
+}
+
+func (d *ConflictDetector) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		panic("invalid conflict interval")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.interval = interval
+}
+
+func (d *ConflictDetector) GetInterval() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.interval
+}
+
+// SetOnConflict sets the function called for every conflicting announcement.
+func (d *ConflictDetector) SetOnConflict(f func(Conflict)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onConflict = f
+}
+
+// SetHalt makes a conflict halt the generator with ErrNodeConflict if it is a
+// Halter; call Resume on the generator once the node has been fixed.
+func (d *ConflictDetector) SetHalt(halt bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.halt = halt
+}
+
+func (d *ConflictDetector) Instance() string {
+	return d.instance
+}
+
+func (d *ConflictDetector) LocalAddr() net.Addr {
+	return d.conn.LocalAddr()
+}
+
+// Start announces the node every interval and handles the announcements of
+// other instances until Stop is called. It panics if the detector has been
+// stopped.
+func (d *ConflictDetector) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		panic("conflict detector is stopped")
+	}
+	if d.stop != nil {
+		return
+	}
+	d.stop = make(chan struct{})
+	d.wg.Add(2)
+	go d.announce(d.stop)
+	go d.listen()
+}
+
+// Stop stops announcing and closes the connection; a stopped detector cannot
+// be started again.
+func (d *ConflictDetector) Stop() {
+	d.mu.Lock()
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+	stopped := d.stopped
+	d.stopped = true
+	d.mu.Unlock()
+	if !stopped {
+		d.conn.Close()
+	}
+	d.wg.Wait()
+}
+
+// Announce sends the current node to the peers once.
+func (d *ConflictDetector) Announce() error {
+	node, nodeBits := d.gen.GetNode()
+	msg := []byte(fmt.Sprintf("%s %d %d %s", conflictMagic, node, nodeBits, d.instance))
+	d.mu.Lock()
+	peers := d.peers
+	d.mu.Unlock()
+	var errs []error
+	for _, peer := range peers {
+		if _, err := d.conn.WriteToUDP(msg, peer); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (d *ConflictDetector) announce(stop chan struct{}) {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.GetInterval())
+	defer ticker.Stop()
+	for {
+		_ = d.Announce()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *ConflictDetector) listen() {
+	defer d.wg.Done()
+	buf := make([]byte, 512)
+	for {
+		n, peer, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		d.handle(buf[:n], peer)
+	}
+}
+
+func (d *ConflictDetector) handle(msg []byte, peer net.Addr) {
+	var magic, instance string
+	var c Conflict
+	if _, err := fmt.Sscanf(string(msg), "%s %d %d %s", &magic, &c.Node, &c.NodeBits, &instance); err != nil || magic != conflictMagic {
+		return
+	}
+	if instance == d.instance {
+		return
+	}
+	node, nodeBits := d.gen.GetNode()
+	if c.Node != node || c.NodeBits != nodeBits {
+		return
+	}
+	c.Instance, c.Peer = instance, peer
+
+	d.mu.Lock()
+	onConflict, halt := d.onConflict, d.halt
+	d.mu.Unlock()
+	if h, ok := d.gen.(Halter); ok && halt {
+		h.Halt(fmt.Errorf("%w: node %d announced by %s (%s)", ErrNodeConflict, c.Node, c.Instance, peer))
+	}
+	if onConflict != nil {
+		onConflict(c)
+	}
+}
@@ -0,0 +1,127 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newLoopbackDetectors(t *testing.T, g1, g2 Generator) (*ConflictDetector, *ConflictDetector) {
+	d1, err := NewConflictDetector(g1, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d1.Stop)
+	d2, err := NewConflictDetector(g2, "127.0.0.1:0", d1.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d2.Stop)
+	if err = d1.SetPeers(d2.LocalAddr().String()); err != nil {
+		t.Fatal(err)
+	}
+	return d1, d2
+}
+
+func TestConflictDetector(t *testing.T) {
+	g1, g2 := NewID(), NewID()
+	g1.SetNode(3, 4)
+	g2.SetNode(3, 4)
+	d1, d2 := newLoopbackDetectors(t, g1, g2)
+	if d1.Instance() == d2.Instance() {
+		t.Fatal("instances must differ")
+	}
+
+	conflicts := make(chan Conflict, 16)
+	d2.SetOnConflict(func(c Conflict) { conflicts <- c })
+	d2.SetHalt(true)
+	d1.SetInterval(10 * time.Millisecond)
+	d1.Start()
+	d2.Start()
+
+	select {
+	case c := <-conflicts:
+		if c.Node != 3 || c.NodeBits != 4 || c.Instance != d1.Instance() {
+			t.Errorf("unexpected conflict %+v", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no conflict detected")
+	}
+
+	if _, err := g2.TryGenerate(); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("TryGenerate() error = %v, want %v", err, ErrNodeConflict)
+	}
+	if _, err := g1.TryGenerate(); err != nil {
+		t.Errorf("TryGenerate() error = %v, detector of g1 does not halt", err)
+	}
+	g2.Resume()
+	if _, err := g2.TryGenerate(); err != nil {
+		t.Errorf("TryGenerate() error = %v after Resume", err)
+	}
+}
+
+func TestConflictDetector_NoConflict(t *testing.T) {
+	g1, g2 := NewID3(), NewID3()
+	g1.SetNode(1, 4)
+	g2.SetNode(2, 4)
+	d1, d2 := newLoopbackDetectors(t, g1, g2)
+
+	conflicts := make(chan Conflict, 16)
+	d1.SetOnConflict(func(c Conflict) { conflicts <- c })
+	d2.SetOnConflict(func(c Conflict) { conflicts <- c })
+	d1.SetHalt(true)
+	d2.SetHalt(true)
+	d1.Start()
+	d2.Start()
+	for i := 0; i < 5; i++ {
+		if err := d1.Announce(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d2.Announce(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case c := <-conflicts:
+		t.Errorf("unexpected conflict %+v", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := g1.TryGenerate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConflictDetector_Stop(t *testing.T) {
+	d, err := NewConflictDetector(NewID(), "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Start()
+	d.Stop()
+	d.Stop()
+	defer func() {
+		if recover() == nil {
+			t.Error("Start() after Stop() did not panic")
+		}
+	}()
+	d.Start()
+}
+
+func TestHalt(t *testing.T) {
+	halt := errors.New("halted")
+	for _, g := range []interface {
+		TryGenerate() (int64, error)
+		Halt(err error)
+		Resume()
+	}{NewID(), NewID2(), NewID3(), NewHLC()} {
+		g.Halt(halt)
+		if _, err := g.TryGenerate(); err != halt {
+			t.Errorf("%T.TryGenerate() error = %v, want %v", g, err, halt)
+		}
+		g.Resume()
+		if _, err := g.TryGenerate(); err != nil {
+			t.Errorf("%T.TryGenerate() error = %v", g, err)
+		}
+	}
+}
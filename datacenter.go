@@ -0,0 +1,63 @@
+package goid
+
+// DatacenterWorkerNode splits the node segment of a generator into a
+// datacenter and a worker.
+type DatacenterWorkerNode interface {
+	SetDatacenterWorker(dc, worker uint32, dcBits, workerBits uint8)
+	GetDatacenterWorker() (dc, worker uint32, dcBits, workerBits uint8)
+}
+
+var (
+	_ DatacenterWorkerNode = (*ID)(nil)
+	_ DatacenterWorkerNode = (*ID2)(nil)
+	_ DatacenterWorkerNode = (*ID3)(nil)
+)
+
+// datacenterWorker combines dc and worker into a node of dcBits+workerBits,
+// the datacenter in the high bits.
+func datacenterWorker(dc, worker uint32, dcBits, workerBits uint8) (node uint32, nodeBits uint8) {
+	if dcBits < 1 || workerBits < 1 ||
+		dc > (1<<dcBits-1) ||
+		worker > (1<<workerBits-1) {
+		panic("datacenter or worker is invalid")
+	}
+	return dc<<workerBits | worker, dcBits + workerBits
+}
+
+func splitNode(node uint32, nodeBits, workerBits uint8) (dc, worker uint32, dcBits, wBits uint8) {
+	if workerBits == 0 {
+		return 0, node, 0, nodeBits
+	}
+	return node >> workerBits, node & (1<<workerBits - 1), nodeBits - workerBits, workerBits
+}
+
+// SetDatacenterWorker splits the node segment into a datacenter of dcBits
+// and a worker of workerBits; SetNode makes the node opaque again.
+func (i *ID) SetDatacenterWorker(dc, worker uint32, dcBits, workerBits uint8) {
+	i.SetNode(datacenterWorker(dc, worker, dcBits, workerBits))
+	i.workerBits = workerBits
+}
+
+// GetDatacenterWorker returns the whole node as the worker if the node segment
+// is not split.
+func (i *ID) GetDatacenterWorker() (dc, worker uint32, dcBits, workerBits uint8) {
+	return splitNode(i.node, i.nodeBits, i.workerBits)
+}
+
+func (i *ID2) SetDatacenterWorker(dc, worker uint32, dcBits, workerBits uint8) {
+	i.SetNode(datacenterWorker(dc, worker, dcBits, workerBits))
+	i.workerBits = workerBits
+}
+
+func (i *ID2) GetDatacenterWorker() (dc, worker uint32, dcBits, workerBits uint8) {
+	return splitNode(i.node, i.nodeBits, i.workerBits)
+}
+
+func (i *ID3) SetDatacenterWorker(dc, worker uint32, dcBits, workerBits uint8) {
+	i.SetNode(datacenterWorker(dc, worker, dcBits, workerBits))
+	i.workerBits = workerBits
+}
+
+func (i *ID3) GetDatacenterWorker() (dc, worker uint32, dcBits, workerBits uint8) {
+	return splitNode(i.node, i.nodeBits, i.workerBits)
+}
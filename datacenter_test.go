@@ -0,0 +1,74 @@
+package goid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetDatacenterWorker(t *testing.T) {
+	for _, g := range []interface {
+		Generator
+		DatacenterWorkerNode
+	}{NewID(), NewID2(), NewID3()} {
+		g.SetDatacenterWorker(2, 5, 3, 5)
+		if node, nodeBits := g.GetNode(); node != 2<<5|5 || nodeBits != 8 {
+			t.Errorf("%T.GetNode() = %d, %d, want %d, 8", g, node, nodeBits, 2<<5|5)
+		}
+		if dc, worker, dcBits, workerBits := g.GetDatacenterWorker(); dc != 2 || worker != 5 || dcBits != 3 || workerBits != 5 {
+			t.Errorf("%T.GetDatacenterWorker() = %d, %d, %d, %d", g, dc, worker, dcBits, workerBits)
+		}
+
+		p := g.Decompose(g.Generate())
+		if p.Node != 2<<5|5 || p.Datacenter != 2 || p.Worker != 5 {
+			t.Errorf("%T.Decompose() = %+v", g, p)
+		}
+		if l := g.Layout(); l.NodeBits != 8 || l.WorkerBits != 5 {
+			t.Errorf("%T.Layout() = %+v", g, l)
+		}
+
+		g.SetNode(7, 4)
+		if dc, worker, dcBits, workerBits := g.GetDatacenterWorker(); dc != 0 || worker != 7 || dcBits != 0 || workerBits != 4 {
+			t.Errorf("%T.GetDatacenterWorker() after SetNode = %d, %d, %d, %d", g, dc, worker, dcBits, workerBits)
+		}
+		if p := g.Decompose(g.Generate()); p.Node != 7 || p.Datacenter != 0 || p.Worker != 0 {
+			t.Errorf("%T.Decompose() after SetNode = %+v", g, p)
+		}
+	}
+}
+
+func TestSetDatacenterWorker_Invalid(t *testing.T) {
+	tests := []struct {
+		name               string
+		dc, worker         uint32
+		dcBits, workerBits uint8
+	}{
+		{"zero dc bits", 0, 1, 0, 4},
+		{"zero worker bits", 1, 0, 4, 0},
+		{"dc out of range", 8, 1, 3, 4},
+		{"worker out of range", 1, 16, 3, 4},
+		{"too wide", 1, 1, 10, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			NewID().SetDatacenterWorker(tt.dc, tt.worker, tt.dcBits, tt.workerBits)
+		})
+	}
+}
+
+func TestSetDatacenterWorker_Compose(t *testing.T) {
+	g := NewID3()
+	g.SetDatacenterWorker(1, 3, 2, 3)
+	now := time.UnixMilli(time.Now().UnixMilli())
+	id, err := g.Compose(now, 1<<3|3, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Decompose(id); p.Datacenter != 1 || p.Worker != 3 || p.Sequence != 9 || !p.Time.Equal(now) {
+		t.Errorf("Decompose() = %+v", p)
+	}
+}
@@ -0,0 +1,17 @@
+package goid
+
+import "errors"
+
+var (
+	ErrTimestampOverflow = errors.New("timestamp exceeds the layout's timestamp segment")
+	ErrNTPServerNotSet   = errors.New("ntp server not set")
+	ErrNTPTime           = errors.New("ntp time error")
+	ErrNodeOutOfRange    = errors.New("node exceeds the layout's node segment")
+	ErrNodeBits          = errors.New("node bits do not fit the layout")
+	ErrShardOutOfRange   = errors.New("shard exceeds the layout's shard segment")
+	ErrSeqOutOfRange     = errors.New("sequence exceeds the layout's sequence segment")
+	ErrSubnetTooLarge    = errors.New("subnet has more hosts than the node segment")
+	ErrNoSubnetAddress   = errors.New("no address in subnet")
+	ErrNoOrdinal         = errors.New("no ordinal found")
+	ErrNodeConflict      = errors.New("node is used by another instance")
+)
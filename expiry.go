@@ -0,0 +1,27 @@
+package goid
+
+import "time"
+
+const (
+	DefaultExpiryWindow = 365 * 24 * time.Hour
+)
+
+// SetExpiryWindow sets how long before the timestamp segment overflows an
+// EventTimestampExpiring is emitted; 0 disables the warning.
+func (h *hooks) SetExpiryWindow(d time.Duration) {
+	if d < 0 {
+		panic("invalid expiryWindow")
+	}
+	h.expiryWindow = d
+	h.expiryWarned.Store(false)
+}
+
+func (h *hooks) GetExpiryWindow() time.Duration {
+	return h.expiryWindow
+}
+
+func (h *hooks) checkExpiry(remaining time.Duration) {
+	if remaining < h.expiryWindow && h.expiryWarned.CompareAndSwap(false, true) {
+		h.emit(Event{Kind: EventTimestampExpiring, Remaining: remaining})
+	}
+}
@@ -0,0 +1,107 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTryGenerate_TimestampOverflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxTime time.Time
+		gen     func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) }
+	}{
+		{"ID", time.Unix(1<<32-1, 0), func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) } {
+			id := NewID()
+			id.SetTimeSource(ts)
+			id.SetObserver(o)
+			return id
+		}},
+		{"ID2", time.Unix(1<<33-1, 0), func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) } {
+			id := NewID2()
+			id.SetTimeSource(ts)
+			id.SetObserver(o)
+			return id
+		}},
+		{"ID3", time.UnixMilli(1<<42 - 1), func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) } {
+			id := NewID3()
+			id.SetTimeSource(ts)
+			id.SetObserver(o)
+			return id
+		}},
+		{"ID3_43", time.UnixMilli(1<<43 - 1), func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) } {
+			id := NewID3()
+			id.SetBits(43)
+			id.SetTimeSource(ts)
+			id.SetObserver(o)
+			return id
+		}},
+		{"HLC", time.UnixMilli(1<<42 - 1), func(ts TimeSource, o Observer) interface{ TryGenerate() (int64, error) } {
+			id := NewHLC()
+			id.SetTimeSource(ts)
+			id.SetObserver(o)
+			return id
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := tt.maxTime
+			o := &countingObserver{}
+			g := tt.gen(TimeSourceFunc(func() time.Time { return now }), o)
+			idV, err := g.TryGenerate()
+			if err != nil || idV <= 0 || idV >= 1<<MaxBits {
+				t.Fatalf("TryGenerate() = %d, %v at the last tick", idV, err)
+			}
+			g.TryGenerate()
+			if n := o.count(EventTimestampExpiring); n != 1 {
+				t.Errorf("got %d %v events, want 1", n, EventTimestampExpiring)
+			}
+
+			now = tt.maxTime.Add(time.Second)
+			if _, err = g.TryGenerate(); !errors.Is(err, ErrTimestampOverflow) {
+				t.Errorf("TryGenerate() error = %v, want %v", err, ErrTimestampOverflow)
+			}
+		})
+	}
+}
+
+func TestGenerate_TimestampOverflowPanics(t *testing.T) {
+	id := NewID()
+	id.SetTimeSource(TimeSourceFunc(func() time.Time { return time.Unix(1<<32, 0) }))
+	defer func() {
+		if r := recover(); r != ErrTimestampOverflow {
+			t.Errorf("Generate() panicked with %v, want %v", r, ErrTimestampOverflow)
+		}
+	}()
+	id.Generate()
+}
+
+func TestSetExpiryWindow(t *testing.T) {
+	o := &countingObserver{}
+	id := NewID3()
+	id.SetObserver(o)
+	id.Generate()
+	if n := o.count(EventTimestampExpiring); n != 0 {
+		t.Fatalf("got %d %v events, want 0", n, EventTimestampExpiring)
+	}
+
+	id.SetExpiryWindow(100 * 365 * 24 * time.Hour)
+	id.Generate()
+	id.Generate()
+	if n := o.count(EventTimestampExpiring); n != 1 {
+		t.Fatalf("got %d %v events, want 1", n, EventTimestampExpiring)
+	}
+	if r := o.events[EventTimestampExpiring][0].Remaining; r > 100*365*24*time.Hour || r < 50*365*24*time.Hour {
+		t.Errorf("Remaining = %v", r)
+	}
+}
+
+func TestTryGenerate_NTPServerNotSet(t *testing.T) {
+	id := NewID3()
+	id.SetTimeSource(TimeSourceFunc(func() time.Time { return time.Now().Add(-time.Minute) }))
+	id.id = time.Now().UnixMilli() << (MaxBits - id.bits)
+	if _, err := id.TryGenerate(); !errors.Is(err, ErrNTPServerNotSet) {
+		t.Errorf("TryGenerate() error = %v, want %v", err, ErrNTPServerNotSet)
+	}
+}
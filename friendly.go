@@ -0,0 +1,201 @@
+package goid
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrChecksum = errors.New("id checksum mismatch")
+
+type Checksum uint8
+
+const (
+	NoChecksum Checksum = iota
+	// Luhn appends a decimal check digit catching single digit errors and
+	// most transpositions of adjacent digits.
+	Luhn
+	// Damm appends a decimal check digit catching all single digit errors
+	// and all transpositions of adjacent digits.
+	Damm
+	// Mod37 appends Crockford's base32 check symbol, one of the 32 digits or
+	// *~$=U, to base32 IDs.
+	Mod37
+)
+
+// crockfordCheck extends the upper case Crockford alphabet by the check
+// symbols for the values 32 to 36.
+const crockfordCheck = "0123456789ABCDEFGHJKMNPQRSTVWXYZ*~$=U"
+
+// Format turns IDs into strings meant to be read out and typed in by people,
+// e.g. order numbers. Base32 selects Crockford's base32, which has no
+// ambiguous letters, instead of decimal; Luhn and Damm require decimal and
+// Mod37 requires base32. If GroupSize is set the characters are split into
+// groups of that size, from the left, joined by Separator ("-" by default).
+type Format struct {
+	Base32    bool
+	Checksum  Checksum
+	GroupSize int
+	Separator string
+}
+
+// HumanFormat formats IDs as grouped base32 with a check symbol, such as
+// 1JKM-4X0P-Q3S.
+var HumanFormat = Format{Base32: true, Checksum: Mod37, GroupSize: 4}
+
+func (f Format) check() {
+	if f.GroupSize < 0 || f.Checksum > Mod37 ||
+		f.Base32 && (f.Checksum == Luhn || f.Checksum == Damm) ||
+		!f.Base32 && f.Checksum == Mod37 {
+		panic("invalid id format")
+	}
+}
+
+func (f Format) Format(id int64) string {
+	f.check()
+	var s string
+	if f.Base32 {
+		var b [base32Width]byte
+		n := len(b)
+		for u := uint64(id); n == len(b) || u > 0; u >>= 5 {
+			n--
+			b[n] = crockfordCheck[u&31]
+		}
+		s = string(b[n:])
+	} else {
+		s = strconv.FormatUint(uint64(id), 10)
+	}
+	switch f.Checksum {
+	case Luhn:
+		s += string(rune('0' + luhn(s)))
+	case Damm:
+		s += string(rune('0' + damm(s)))
+	case Mod37:
+		s += string(crockfordCheck[uint64(id)%37])
+	}
+	if f.GroupSize == 0 || len(s) <= f.GroupSize {
+		return s
+	}
+	sep := f.Separator
+	if sep == "" {
+		sep = "-"
+	}
+	var b strings.Builder
+	for n := 0; n < len(s); n += f.GroupSize {
+		if n > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(s[n:min(n+f.GroupSize, len(s))])
+	}
+	return b.String()
+}
+
+// Parse reads an ID formatted by f. It ignores case, spaces, dots, dashes,
+// underscores and the Separator, reads O as 0 and I and L as 1, and fails
+// with ErrChecksum if the check character does not match.
+func (f Format) Parse(s string) (int64, error) {
+	f.check()
+	norm := make([]byte, 0, len(s))
+	for n := 0; n < len(s); n++ {
+		c := s[n]
+		switch {
+		case c == ' ' || c == '.' || c == '-' || c == '_' || f.Separator != "" && strings.IndexByte(f.Separator, c) >= 0:
+			continue
+		case c == 'o' || c == 'O':
+			c = '0'
+		case c == 'i' || c == 'I' || c == 'l' || c == 'L':
+			c = '1'
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		norm = append(norm, c)
+	}
+	body := string(norm)
+	if f.Checksum != NoChecksum {
+		if len(norm) < 2 {
+			return 0, fmt.Errorf("%w: %q", ErrBadFormat, s)
+		}
+		body = body[:len(body)-1]
+	}
+
+	var id int64
+	if f.Base32 {
+		if len(body) == 0 || len(body) > base32Width {
+			return 0, fmt.Errorf("%w: %q", ErrBadFormat, s)
+		}
+		var u uint64
+		for n := 0; n < len(body); n++ {
+			v := crockfordValues[body[n]]
+			if v < 0 || n == 0 && len(body) == base32Width && v > 15 {
+				return 0, fmt.Errorf("%w: %q", ErrBadFormat, s)
+			}
+			u = u<<5 | uint64(v)
+		}
+		id = int64(u)
+	} else {
+		for n := 0; n < len(body); n++ {
+			if body[n] < '0' || body[n] > '9' {
+				return 0, fmt.Errorf("%w: %q", ErrBadFormat, s)
+			}
+		}
+		u, err := strconv.ParseUint(body, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrBadFormat, s)
+		}
+		id = int64(u)
+	}
+
+	var ok bool
+	switch check := norm[len(norm)-1]; f.Checksum {
+	case NoChecksum:
+		ok = true
+	case Luhn:
+		ok = check == '0'+luhn(body)
+	case Damm:
+		ok = check == '0'+damm(body)
+	case Mod37:
+		ok = check == crockfordCheck[uint64(id)%37]
+	}
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrChecksum, s)
+	}
+	return id, nil
+}
+
+// luhn returns the Luhn check digit of the decimal digits s.
+func luhn(s string) byte {
+	sum := 0
+	for n := 0; n < len(s); n++ {
+		d := int(s[len(s)-1-n] - '0')
+		if n%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte((10 - sum%10) % 10)
+}
+
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+// damm returns the Damm check digit of the decimal digits s.
+func damm(s string) byte {
+	var interim byte
+	for n := 0; n < len(s); n++ {
+		interim = dammTable[interim][s[n]-'0']
+	}
+	return interim
+}
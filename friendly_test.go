@@ -0,0 +1,144 @@
+package goid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestCheckDigits(t *testing.T) {
+	if d := luhn("7992739871"); d != 3 {
+		t.Errorf("luhn(7992739871) = %d, want 3", d)
+	}
+	if d := damm("572"); d != 4 {
+		t.Errorf("damm(572) = %d, want 4", d)
+	}
+}
+
+func TestFormat_RoundTrip(t *testing.T) {
+	formats := []Format{
+		{},
+		{Checksum: Luhn},
+		{Checksum: Damm, GroupSize: 3, Separator: " "},
+		{Base32: true},
+		HumanFormat,
+		{Base32: true, Checksum: Mod37, GroupSize: 5, Separator: "."},
+	}
+	for _, f := range formats {
+		check := func(id int64) bool {
+			got, err := f.Parse(f.Format(id))
+			return err == nil && got == id
+		}
+		if err := quick.Check(check, nil); err != nil {
+			t.Errorf("%+v: %v", f, err)
+		}
+		for _, id := range []int64{0, 1, 36, 37, 1<<53 - 1, -1} {
+			if !check(id) {
+				t.Errorf("%+v: %d does not round trip through %q", f, id, f.Format(id))
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		f    Format
+		id   int64
+		want string
+	}{
+		{Format{}, 1234567, "1234567"},
+		{Format{Checksum: Luhn}, 7992739871, "79927398713"},
+		{Format{Checksum: Damm, GroupSize: 2}, 572, "57-24"},
+		{Format{Base32: true}, 32*32*31 + 32*18 + 22, "ZJP"},
+		{Format{Base32: true, Checksum: Mod37}, 37*2 + 36, "3EU"},
+		{Format{Base32: true, Checksum: Mod37, GroupSize: 2, Separator: " "}, 32*32*31 + 32*18 + 22, "ZJ P4"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Format(tt.id); got != tt.want {
+			t.Errorf("%+v.Format(%d) = %q, want %q", tt.f, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestFormat_Parse(t *testing.T) {
+	const id = 3670905216090113 // 38AN-CWPH-G01U, check symbol U
+	s := HumanFormat.Format(id)
+
+	for _, typed := range []string{
+		s,
+		strings.ToLower(s),
+		strings.ReplaceAll(s, "-", ""),
+		strings.ReplaceAll(s, "-", " "),
+		" " + strings.ReplaceAll(strings.ReplaceAll(s, "0", "o"), "1", "l") + " ",
+	} {
+		if got, err := HumanFormat.Parse(typed); err != nil || got != id {
+			t.Errorf("Parse(%q) = %d, %v, want %d", typed, got, err, id)
+		}
+	}
+
+	// Every single character error and adjacent transposition is caught.
+	plain := strings.ReplaceAll(s, "-", "")
+	for n := 0; n < len(plain)-1; n++ {
+		for _, c := range crockfordCheck[:32] {
+			if byte(c) == plain[n] {
+				continue
+			}
+			typo := plain[:n] + string(c) + plain[n+1:]
+			if _, err := HumanFormat.Parse(typo); !errors.Is(err, ErrChecksum) && !errors.Is(err, ErrBadFormat) {
+				t.Errorf("Parse(%q) error = %v, want a checksum error", typo, err)
+			}
+		}
+		if plain[n] != plain[n+1] {
+			swapped := plain[:n] + plain[n+1:n+2] + plain[n:n+1] + plain[n+2:]
+			// Swapping the check symbol into the body may leave a symbol
+			// that is not valid there.
+			_, err := HumanFormat.Parse(swapped)
+			if !errors.Is(err, ErrChecksum) && (n+2 < len(plain) || !errors.Is(err, ErrBadFormat)) {
+				t.Errorf("Parse(%q) error = %v, want %v", swapped, err, ErrChecksum)
+			}
+		}
+	}
+
+	damm := Format{Checksum: Damm, GroupSize: 4}
+	digits := strings.ReplaceAll(damm.Format(id), "-", "")
+	for n := 0; n < len(digits); n++ {
+		for c := byte('0'); c <= '9'; c++ {
+			if c == digits[n] {
+				continue
+			}
+			typo := digits[:n] + string(c) + digits[n+1:]
+			if _, err := damm.Parse(typo); !errors.Is(err, ErrChecksum) {
+				t.Errorf("Parse(%q) error = %v, want %v", typo, err, ErrChecksum)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "7", "12a4", "99999999999999999999999"} {
+		if _, err := (Format{Checksum: Luhn}).Parse(bad); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("Parse(%q) error = %v, want %v", bad, err, ErrBadFormat)
+		}
+	}
+	if _, err := HumanFormat.Parse("UUUU"); !errors.Is(err, ErrBadFormat) {
+		t.Errorf("Parse(UUUU) error = %v, want %v", err, ErrBadFormat)
+	}
+}
+
+func TestFormat_Invalid(t *testing.T) {
+	for _, f := range []Format{
+		{Base32: true, Checksum: Luhn},
+		{Base32: true, Checksum: Damm},
+		{Checksum: Mod37},
+		{GroupSize: -1},
+		{Checksum: Mod37 + 1},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%+v.Format() did not panic", f)
+				}
+			}()
+			f.Format(1)
+		}()
+	}
+}
@@ -0,0 +1,90 @@
+package goid
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generator is the generation and decoding core shared by ID, ID2 and ID3.
+// Optional features sit behind smaller interfaces such as Validator and
+// Tagger, so that mocks only implement what they use.
+type Generator interface {
+	Generate() int64
+	Decompose(id int64) Parts
+	Layout() Layout
+	SetNode(node uint32, nodeBits uint8)
+	GetNode() (node uint32, nodeBits uint8)
+	SetDelta(d uint32)
+	GetDelta() uint32
+	SetRandomDelta(r uint32)
+	GetRandomDelta() uint32
+	SetMaxBacktrackWait(d time.Duration)
+	GetMaxBacktrackWait() time.Duration
+	SetNTPServer(s string)
+	GetNTPServer() string
+}
+
+// TryGenerator generates IDs returning the error instead of panicking like
+// Generate, e.g. ErrTimestampOverflow once the timestamp segment is used up
+// or the error a generator was halted with.
+type TryGenerator interface {
+	TryGenerate() (int64, error)
+}
+
+var (
+	_ Generator = (*ID)(nil)
+	_ Generator = (*ID2)(nil)
+	_ Generator = (*ID3)(nil)
+
+	_ TryGenerator = (*ID)(nil)
+	_ TryGenerator = (*ID2)(nil)
+	_ TryGenerator = (*ID3)(nil)
+	_ TryGenerator = (*HLC)(nil)
+)
+
+// NewGenerator creates a generator by layout name: "id", "id2", "id3" or
+// "id3-43" (ID3 with 43 timestamp bits).
+func NewGenerator(name string) (Generator, error) {
+	switch strings.ToLower(name) {
+	case "id":
+		return NewID(), nil
+	case "id2":
+		return NewID2(), nil
+	case "id3":
+		return NewID3(), nil
+	case "id3-43":
+		id := NewID3()
+		id.SetBits(43)
+		return id, nil
+	}
+	return nil, fmt.Errorf("unknown id layout %q", name)
+}
+
+func (i *ID) Layout() Layout {
+	return Layout{Name: "id", TimeBits: 32, TagBits: i.tagBits, Tag: i.tag, ShardBits: i.shardBits, NodeBits: i.nodeBits, WorkerBits: i.workerBits, SeqBits: i.seqBits(), Tick: time.Second}
+}
+
+func (i *ID) Decompose(id int64) Parts {
+	return i.Layout().decompose(id)
+}
+
+func (i *ID2) Layout() Layout {
+	return Layout{Name: "id2", TimeBits: 33, TagBits: i.tagBits, Tag: i.tag, ShardBits: i.shardBits, NodeBits: i.nodeBits, WorkerBits: i.workerBits, SeqBits: i.seqBits(), Tick: time.Second}
+}
+
+func (i *ID2) Decompose(id int64) Parts {
+	return i.Layout().decompose(id)
+}
+
+func (i *ID3) Layout() Layout {
+	name := "id3"
+	if i.bits != 42 {
+		name = fmt.Sprintf("id3-%d", i.bits)
+	}
+	return Layout{Name: name, TimeBits: i.bits, TagBits: i.tagBits, Tag: i.tag, ShardBits: i.shardBits, NodeBits: i.nodeBits, WorkerBits: i.workerBits, SeqBits: i.seqBits(), Tick: time.Millisecond}
+}
+
+func (i *ID3) Decompose(id int64) Parts {
+	return i.Layout().decompose(id)
+}
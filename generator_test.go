@@ -0,0 +1,58 @@
+package goid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   Layout
+		wantErr  bool
+		nodeBits uint8
+	}{
+		{name: "id", layout: Layout{Name: "id", TimeBits: 32, NodeBits: 4, SeqBits: 17, Tick: time.Second}, nodeBits: 4},
+		{name: "ID2", layout: Layout{Name: "id2", TimeBits: 33, NodeBits: 4, SeqBits: 16, Tick: time.Second}, nodeBits: 4},
+		{name: "id3", layout: Layout{Name: "id3", TimeBits: 42, NodeBits: 4, SeqBits: 7, Tick: time.Millisecond}, nodeBits: 4},
+		{name: "id3-43", layout: Layout{Name: "id3-43", TimeBits: 43, NodeBits: 4, SeqBits: 6, Tick: time.Millisecond}, nodeBits: 4},
+		{name: "id4", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGenerator(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewGenerator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			g.SetNode(9, tt.nodeBits)
+			if got := g.Layout(); got != tt.layout {
+				t.Errorf("Layout() = %+v, want %+v", got, tt.layout)
+			}
+
+			before := time.Now().Truncate(tt.layout.Tick)
+			p := g.Decompose(g.Generate())
+			if p.Node != 9 || p.Sequence != 1 {
+				t.Errorf("Decompose() = %+v, want node 9 and sequence 1", p)
+			}
+			if p.Time.Before(before) || p.Time.After(time.Now()) {
+				t.Errorf("Decompose() time %v not current", p.Time)
+			}
+		})
+	}
+}
+
+func TestGenerator_Setters(t *testing.T) {
+	for _, g := range []Generator{NewID(), NewID2(), NewID3()} {
+		g.SetDelta(3)
+		g.SetRandomDelta(5)
+		g.SetMaxBacktrackWait(time.Second)
+		g.SetNTPServer("pool.ntp.org")
+		if g.GetDelta() != 3 || g.GetRandomDelta() != 5 ||
+			g.GetMaxBacktrackWait() != time.Second || g.GetNTPServer() != "pool.ntp.org" {
+			t.Errorf("%T setters not applied", g)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/ace-zhaoy/go-id
 
-go 1.19
+go 1.21
 
 require github.com/beevik/ntp v1.3.1
 
@@ -0,0 +1,170 @@
+package goid
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HLCTimeBits = 42
+
+	maxHLCTimestamp = 1<<HLCTimeBits - 1
+)
+
+var (
+	ErrClockDrift = errors.New("remote clock drift exceeds max drift")
+	ErrRemoteID   = errors.New("remote id does not fit the hlc layout")
+)
+
+// HLC is a hybrid logical clock generator. Its IDs are laid out as
+// millisecond timestamp | logical counter | node, so that IDs minted on
+// different nodes still compare by (physical time, logical counter).
+// When the counter of a millisecond is exhausted the clock moves one
+// millisecond ahead of the wall clock instead of waiting.
+type HLC struct {
+	id         int64 // hot path
+	maxDrift   time.Duration
+	timeSource TimeSource
+	node       uint32
+	nodeBits   uint8
+	width      uint8
+	hooks
+}
+
+func NewHLC() *HLC {
+	return &HLC{
+		width: MaxBits,
+		hooks: hooks{expiryWindow: DefaultExpiryWindow},
+	}
+}
+
+var _hlc = NewHLC()
+
+func GetHLC() *HLC {
+	return _hlc
+}
+
+func GenHLC() int64 {
+	return _hlc.Generate()
+}
+
+func ResolveHLC(id int64, h *HLC) (timestamp int64, counter uint32) {
+	lBits := h.width - HLCTimeBits - h.nodeBits
+	return id >> (h.width - HLCTimeBits), uint32(id>>h.nodeBits) & uint32((1<<lBits)-1)
+}
+
+func (h *HLC) Generate() int64 {
+	id, err := h.TryGenerate()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (h *HLC) TryGenerate() (int64, error) {
+	if err := h.haltErr(); err != nil {
+		return 0, err
+	}
+	for {
+		old := atomic.LoadInt64(&h.id)
+		ncBits := h.width - HLCTimeBits
+		mask := int64((1 << (ncBits - h.nodeBits)) - 1)
+		lt := old >> ncBits
+		lc := (old >> h.nodeBits) & mask
+		nt, nc := h.now().UnixMilli(), int64(0)
+		if nt <= lt {
+			nt, nc = lt, lc+1
+			if nc > mask {
+				nt, nc = lt+1, 0
+			}
+		}
+		if nt > maxHLCTimestamp {
+			return 0, ErrTimestampOverflow
+		}
+		h.checkExpiry(time.Duration(maxHLCTimestamp-nt) * time.Millisecond)
+
+		now := (nt << ncBits) | (nc << h.nodeBits) | int64(h.node)
+		if atomic.CompareAndSwapInt64(&h.id, old, now) {
+			return now, nil
+		}
+		h.emit(Event{Kind: EventCASRetry})
+	}
+}
+
+// Observe advances the clock past remoteID, an ID minted by another node
+// sharing the same layout, so that every ID generated afterwards is greater.
+// It returns ErrRemoteID for an ID outside the layout and ErrClockDrift for one
+// too far ahead, leaving the clock unchanged.
+func (h *HLC) Observe(remoteID int64) error {
+	if remoteID < 0 || remoteID >= 1<<h.width {
+		return ErrRemoteID
+	}
+	// A timestamp at the end of the segment would leave nothing to generate,
+	// whatever the max drift.
+	rt := remoteID >> (h.width - HLCTimeBits)
+	if rt >= maxHLCTimestamp ||
+		h.maxDrift > 0 && time.Duration(rt-h.now().UnixMilli())*time.Millisecond > h.maxDrift {
+		return ErrClockDrift
+	}
+	rk := remoteID >> h.nodeBits
+	for {
+		old := atomic.LoadInt64(&h.id)
+		if old>>h.nodeBits >= rk {
+			return nil
+		}
+		if atomic.CompareAndSwapInt64(&h.id, old, (rk<<h.nodeBits)|int64(h.node)) {
+			return nil
+		}
+	}
+}
+
+func (h *HLC) SetNode(node uint32, nodeBits uint8) {
+	if nodeBits < 2 || nodeBits > (h.width-HLCTimeBits-2) ||
+		node > (1<<nodeBits-1) {
+		panic("node or nodeBits is invalid")
+	}
+	h.node, h.nodeBits = node, nodeBits
+}
+
+func (h *HLC) GetNode() (node uint32, nodeBits uint8) {
+	return h.node, h.nodeBits
+}
+
+func (h *HLC) SetWidth(width uint8) {
+	if (width != MaxBits && width != 63) ||
+		(h.nodeBits > 0 && h.nodeBits > (width-HLCTimeBits-2)) {
+		panic("width is invalid")
+	}
+	h.width = width
+}
+
+func (h *HLC) GetWidth() uint8 {
+	return h.width
+}
+
+func (h *HLC) SetMaxDrift(d time.Duration) {
+	if d < 0 {
+		panic("invalid maxDrift")
+	}
+	h.maxDrift = d
+}
+
+func (h *HLC) GetMaxDrift() time.Duration {
+	return h.maxDrift
+}
+
+func (h *HLC) SetTimeSource(ts TimeSource) {
+	h.timeSource = ts
+}
+
+func (h *HLC) GetTimeSource() TimeSource {
+	return h.timeSource
+}
+
+func (h *HLC) now() time.Time {
+	if h.timeSource != nil {
+		return h.timeSource.Now()
+	}
+	return time.Now()
+}
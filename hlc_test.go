@@ -0,0 +1,133 @@
+package goid
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHLC_Generate_duplicate(t *testing.T) {
+	ll := 1000000
+	idArr := make(map[int64]struct{}, ll)
+	idChan := make(chan int64, ll)
+	id := NewHLC()
+	id.SetNode(3, 4)
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	for j := 0; j < 100; j++ {
+		go func() {
+			defer wg.Done()
+			for k := 0; k < ll/100; k++ {
+				idChan <- id.Generate()
+			}
+		}()
+	}
+	wg.Wait()
+	close(idChan)
+	for idV := range idChan {
+		idArr[idV] = struct{}{}
+	}
+	if len(idArr) != ll {
+		t.Errorf("Duplicate ID generated, want %d, got %d", ll, len(idArr))
+	}
+}
+
+func TestHLC_Generate_increment(t *testing.T) {
+	ll := 1000000
+	var latestID int64
+	id := NewHLC()
+	for i := 0; i < ll; i++ {
+		idV := id.Generate()
+		if idV <= latestID {
+			t.Fatalf("id (%d) <= latestID (%d) ", idV, latestID)
+		}
+		latestID = idV
+		if idV >= 1<<MaxBits {
+			t.Fatalf("id (%d) exceeds %d bits", idV, MaxBits)
+		}
+	}
+}
+
+func TestHLC_Observe(t *testing.T) {
+	remote := NewHLC()
+	remote.SetNode(7, 4)
+	local := NewHLC()
+	local.SetNode(1, 4)
+
+	ahead := (time.Now().Add(time.Hour).UnixMilli() << (MaxBits - HLCTimeBits)) | (5 << 4) | 7
+	if err := local.Observe(ahead); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	idV := local.Generate()
+	if idV <= ahead {
+		t.Errorf("id (%d) <= remote id (%d)", idV, ahead)
+	}
+	ts, c := ResolveHLC(idV, local)
+	if rts, rc := ResolveHLC(ahead, remote); ts != rts || c != rc+1 {
+		t.Errorf("ResolveHLC() = (%d, %d), want (%d, %d)", ts, c, rts, rc+1)
+	}
+	if node := uint32(idV) & 0xf; node != 1 {
+		t.Errorf("node = %d, want 1", node)
+	}
+
+	old := remote.Generate()
+	if err := local.Observe(old); err != nil {
+		t.Fatalf("Observe() error = %v", err)
+	}
+	if next := local.Generate(); next <= idV {
+		t.Errorf("observing an older id moved the clock back: %d <= %d", next, idV)
+	}
+}
+
+func TestHLC_Observe_drift(t *testing.T) {
+	local := NewHLC()
+	local.SetMaxDrift(time.Second)
+	ahead := time.Now().Add(time.Minute).UnixMilli() << (MaxBits - HLCTimeBits)
+	if err := local.Observe(ahead); err != ErrClockDrift {
+		t.Errorf("Observe() error = %v, want %v", err, ErrClockDrift)
+	}
+	if idV := local.Generate(); idV > ahead {
+		t.Errorf("rejected remote id advanced the clock")
+	}
+}
+
+func TestHLC_Observe_invalid(t *testing.T) {
+	for _, tt := range []struct {
+		remoteID int64
+		want     error
+	}{
+		{-1, ErrRemoteID},
+		{1 << MaxBits, ErrRemoteID},
+		{1 << 62, ErrRemoteID},
+		{1<<MaxBits - 1, ErrClockDrift},
+	} {
+		local := NewHLC()
+		if err := local.Observe(tt.remoteID); err != tt.want {
+			t.Errorf("Observe(%d) error = %v, want %v", tt.remoteID, err, tt.want)
+		}
+		if _, err := local.TryGenerate(); err != nil {
+			t.Errorf("TryGenerate() after Observe(%d) error = %v", tt.remoteID, err)
+		}
+	}
+}
+
+func TestHLC_SetWidth(t *testing.T) {
+	id := NewHLC()
+	id.SetWidth(63)
+	id.SetNode(1023, 10)
+	idV := id.Generate()
+	ts, _ := ResolveHLC(idV, id)
+	if d := time.Now().UnixMilli() - ts; d < 0 || d > 1000 {
+		t.Errorf("timestamp %d is not current", ts)
+	}
+	if uint32(idV)&1023 != 1023 {
+		t.Errorf("node not embedded in %d", idV)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetWidth(53) with 10 node bits should panic")
+		}
+	}()
+	id.SetWidth(MaxBits)
+}
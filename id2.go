@@ -1,17 +1,23 @@
 package goid
 
 import (
-	"crypto/rand"
-	"github.com/beevik/ntp"
-	"math/big"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	maxID2Timestamp = 1<<33 - 1
+)
+
 func NewID2() *ID2 {
 	return &ID2{
 		delta:            1,
 		maxBacktrackWait: 3 * time.Second,
+		randSource:       newRandSource(),
+		hooks:            hooks{expiryWindow: DefaultExpiryWindow},
+		validator:        validator{validSkew: DefaultValidSkew},
 	}
 }
 
@@ -26,47 +32,107 @@ func GenID2() int64 {
 }
 
 func ResolveID2(id int64, oid *ID2) (timestamp int64, counter uint32) {
-	return id >> 20, uint32(id) & uint32((1<<(20-oid.nodeBits))-1)
+	return id >> 20, uint32(id) & uint32((1<<oid.seqBits())-1)
 }
 
 type ID2 struct {
 	id               int64
+	shards           sync.Map
 	maxBacktrackWait time.Duration
 	ntpServer        string
+	ntpClient        *NTPClient
+	timeSource       TimeSource
+	randSource       RandSource
 	delta            uint32
 	randomDelta      uint32
 	node             uint32
 	nodeBits         uint8
+	workerBits       uint8
+	tag              uint32
+	tagBits          uint8
+	shardBits        uint8
+	hooks
+	validator
 }
 
 func (i *ID2) Generate() int64 {
+	id, err := i.TryGenerate()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (i *ID2) TryGenerate() (int64, error) {
+	return i.generate(&i.id, 0)
+}
+
+func (i *ID2) generate(cell *int64, shard uint32) (int64, error) {
+	if err := i.haltErr(); err != nil {
+		return 0, err
+	}
+	var waiting, exhausted bool
+	var waitStart time.Time
 	for {
-		old := atomic.LoadInt64(&i.id)
-		nt := time.Now().Unix()
-		lt := (old >> 20) & ((1 << 33) - 1)
-		cBits := 20 - i.nodeBits
+		old := atomic.LoadInt64(cell)
+		nt := i.now().Unix()
+		if nt > maxID2Timestamp {
+			return 0, ErrTimestampOverflow
+		}
+		lt := (old >> 20) & maxID2Timestamp
+		cBits := i.seqBits()
 		mask := uint32((1 << cBits) - 1)
 		ct := uint32(old) & mask
 		if nt < lt {
 			if time.Duration(lt-nt)*time.Second <= i.maxBacktrackWait {
+				if !waiting {
+					waiting = true
+					waitStart = time.Now()
+					i.emit(Event{Kind: EventBacktrackWait, Behind: time.Duration(lt-nt) * time.Second})
+				}
 				time.Sleep(time.Millisecond)
 				continue
 			}
-			if i.ntpServer == "" {
-				panic("ntp server not set")
+			if i.ntpClient == nil {
+				return 0, ErrNTPServerNotSet
 			}
-			ntTime, err := ntp.Time(i.ntpServer)
+			waiting = false
+			start := time.Now()
+			offset, err := i.ntpClient.Offset()
+			if err == nil && time.Now().Add(offset).Unix() < lt {
+				offset, err = i.ntpClient.Measure()
+			}
+			i.emit(Event{
+				Kind:    EventNTPFallback,
+				Behind:  time.Duration(lt-nt) * time.Second,
+				Server:  i.ntpServer,
+				Offset:  offset,
+				Latency: time.Since(start),
+				Err:     err,
+			})
 			if err != nil {
-				panic(err)
+				return 0, err
+			}
+			nt = time.Now().Add(offset).Unix()
+			if nt > maxID2Timestamp {
+				return 0, ErrTimestampOverflow
 			}
-			nt = ntTime.Unix()
 			if nt < lt {
-				panic("ntp time error")
+				return 0, ErrNTPTime
 			}
 		}
+		if waiting {
+			waiting = false
+			i.emit(Event{Kind: EventBacktrackResolved, Waited: time.Since(waitStart)})
+		}
+		i.checkExpiry(time.Duration(maxID2Timestamp-nt) * time.Second)
 		if nt == lt {
 			ct += i.getDelta()
 			if ct > mask {
+				if !exhausted {
+					exhausted = true
+					i.emit(Event{Kind: EventSequenceExhausted})
+				}
 				time.Sleep(time.Millisecond)
 				continue
 			}
@@ -78,23 +144,28 @@ func (i *ID2) Generate() int64 {
 		if i.nodeBits > 0 {
 			now |= int64(i.node) << cBits
 		}
-		if atomic.CompareAndSwapInt64(&i.id, old, now) {
-			return now
+		if i.shardBits > 0 {
+			now |= int64(shard) << (cBits + i.nodeBits)
+		}
+		if i.tagBits > 0 {
+			now |= int64(i.tag) << (cBits + i.nodeBits + i.shardBits)
+		}
+		if atomic.CompareAndSwapInt64(cell, old, now) {
+			return now, nil
 		}
+		i.emit(Event{Kind: EventCASRetry})
 	}
 }
 
 func (i *ID2) getDelta() uint32 {
 	if i.randomDelta > 0 {
-		if de, err := rand.Int(rand.Reader, big.NewInt(int64(i.randomDelta))); err == nil {
-			return uint32(de.Int64() + 1)
-		}
+		return randomDelta(i.randSource, i.randomDelta)
 	}
 	return i.delta
 }
 
 func (i *ID2) SetDelta(d uint32) {
-	if d == 0 || d >= (1<<(20-i.nodeBits)-1) {
+	if d == 0 || d >= (1<<i.seqBits()-1) {
 		panic("delta too large or invalid")
 	}
 	i.delta = d
@@ -105,7 +176,7 @@ func (i *ID2) GetDelta() uint32 {
 }
 
 func (i *ID2) SetRandomDelta(r uint32) {
-	if r == 0 || r >= (1<<(20-i.nodeBits)-1) {
+	if r == 0 || r >= (1<<i.seqBits()-1) {
 		panic("random delta too large or invalid")
 	}
 	i.randomDelta = r
@@ -115,14 +186,25 @@ func (i *ID2) GetRandomDelta() uint32 {
 	return i.randomDelta
 }
 
+func (i *ID2) SetRandSource(src RandSource) {
+	if src == nil {
+		panic("rand source is nil")
+	}
+	i.randSource = src
+}
+
+func (i *ID2) GetRandSource() RandSource {
+	return i.randSource
+}
+
 func (i *ID2) SetNode(node uint32, nodeBits uint8) {
-	if nodeBits < 2 || nodeBits > 18 ||
+	if nodeBits < 2 || nodeBits+i.tagBits+i.shardBits > 18 ||
 		node > (1<<nodeBits-1) ||
-		i.delta >= (1<<(20-nodeBits)-1) ||
-		i.randomDelta >= (1<<(20-nodeBits)-1) {
+		i.delta >= (1<<(20-i.tagBits-i.shardBits-nodeBits)-1) ||
+		i.randomDelta >= (1<<(20-i.tagBits-i.shardBits-nodeBits)-1) {
 		panic("node or nodeBits is invalid")
 	}
-	i.node, i.nodeBits = node, nodeBits
+	i.node, i.nodeBits, i.workerBits = node, nodeBits, 0
 }
 
 func (i *ID2) GetNode() (node uint32, nodeBits uint8) {
@@ -130,6 +212,9 @@ func (i *ID2) GetNode() (node uint32, nodeBits uint8) {
 }
 
 func (i *ID2) SetMaxBacktrackWait(d time.Duration) {
+	if d < 0 {
+		panic("invalid maxBacktrackWait")
+	}
 	i.maxBacktrackWait = d
 }
 
@@ -139,4 +224,40 @@ func (i *ID2) GetMaxBacktrackWait() time.Duration {
 
 func (i *ID2) SetNTPServer(s string) {
 	i.ntpServer = s
+	if s == "" {
+		i.ntpClient = nil
+		return
+	}
+	i.ntpClient = NewNTPClient(s)
+}
+
+func (i *ID2) GetNTPServer() string {
+	return i.ntpServer
+}
+
+func (i *ID2) SetNTPClient(c *NTPClient) {
+	i.ntpClient = c
+	i.ntpServer = ""
+	if c != nil {
+		i.ntpServer = strings.Join(c.GetServers(), ",")
+	}
+}
+
+func (i *ID2) GetNTPClient() *NTPClient {
+	return i.ntpClient
+}
+
+func (i *ID2) SetTimeSource(ts TimeSource) {
+	i.timeSource = ts
+}
+
+func (i *ID2) GetTimeSource() TimeSource {
+	return i.timeSource
+}
+
+func (i *ID2) now() time.Time {
+	if i.timeSource != nil {
+		return i.timeSource.Now()
+	}
+	return time.Now()
 }
@@ -9,3 +9,24 @@ func BenchmarkID2_Generate(b *testing.B) {
 		id.Generate()
 	}
 }
+
+func BenchmarkID2_Generate_RandomDelta(b *testing.B) {
+	id := NewID2()
+	id.SetRandomDelta(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkID2_Generate_CryptoRandomDelta(b *testing.B) {
+	id := NewID2()
+	id.SetRandomDelta(16)
+	id.SetRandSource(CryptoRandSource)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
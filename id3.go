@@ -1,9 +1,8 @@
 package goid
 
 import (
-	"crypto/rand"
-	"github.com/beevik/ntp"
-	"math/big"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -14,44 +13,105 @@ const (
 
 type ID3 struct {
 	id               int64
+	shards           sync.Map
 	maxBacktrackWait time.Duration
 	ntpServer        string
-	randomDelta      uint16
-	node             uint16
-	delta            uint16
+	ntpClient        *NTPClient
+	timeSource       TimeSource
+	randSource       RandSource
+	randomDelta      uint32
+	node             uint32
+	delta            uint32
 	nodeBits         uint8
+	workerBits       uint8
+	tag              uint32
+	tagBits          uint8
+	shardBits        uint8
 	bits             uint8
+	hooks
+	validator
 }
 
 func (i *ID3) Generate() int64 {
+	id, err := i.TryGenerate()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (i *ID3) TryGenerate() (int64, error) {
+	return i.generate(&i.id, 0)
+}
+
+func (i *ID3) generate(cell *int64, shard uint32) (int64, error) {
+	if err := i.haltErr(); err != nil {
+		return 0, err
+	}
+	var waiting, exhausted bool
+	var waitStart time.Time
 	for {
-		old := atomic.LoadInt64(&i.id)
-		nt := time.Now().UnixMilli()
+		old := atomic.LoadInt64(cell)
+		nt := i.now().UnixMilli()
 		ncbits := MaxBits - i.bits
-		lt := (old >> ncbits) & ((1 << i.bits) - 1)
-		cBits := ncbits - i.nodeBits
-		mask := uint16((1 << cBits) - 1)
-		ct := uint16(old) & mask
+		maxTimestamp := int64(1<<i.bits - 1)
+		if nt > maxTimestamp {
+			return 0, ErrTimestampOverflow
+		}
+		lt := (old >> ncbits) & maxTimestamp
+		cBits := i.seqBits()
+		mask := uint32((1 << cBits) - 1)
+		ct := uint32(old) & mask
 		if nt < lt {
 			if time.Duration(lt-nt)*time.Millisecond <= i.maxBacktrackWait {
+				if !waiting {
+					waiting = true
+					waitStart = time.Now()
+					i.emit(Event{Kind: EventBacktrackWait, Behind: time.Duration(lt-nt) * time.Millisecond})
+				}
 				time.Sleep(time.Millisecond)
 				continue
 			}
-			if i.ntpServer == "" {
-				panic("ntp server not set")
+			if i.ntpClient == nil {
+				return 0, ErrNTPServerNotSet
+			}
+			waiting = false
+			start := time.Now()
+			offset, err := i.ntpClient.Offset()
+			if err == nil && time.Now().Add(offset).UnixMilli() < lt {
+				offset, err = i.ntpClient.Measure()
 			}
-			ntTime, err := ntp.Time(i.ntpServer)
+			i.emit(Event{
+				Kind:    EventNTPFallback,
+				Behind:  time.Duration(lt-nt) * time.Millisecond,
+				Server:  i.ntpServer,
+				Offset:  offset,
+				Latency: time.Since(start),
+				Err:     err,
+			})
 			if err != nil {
-				panic(err)
+				return 0, err
+			}
+			nt = time.Now().Add(offset).UnixMilli()
+			if nt > maxTimestamp {
+				return 0, ErrTimestampOverflow
 			}
-			nt = ntTime.UnixMilli()
 			if nt < lt {
-				panic("ntp time error")
+				return 0, ErrNTPTime
 			}
 		}
+		if waiting {
+			waiting = false
+			i.emit(Event{Kind: EventBacktrackResolved, Waited: time.Since(waitStart)})
+		}
+		i.checkExpiry(time.Duration(maxTimestamp-nt) * time.Millisecond)
 		if nt == lt {
 			ct += i.getDelta()
 			if ct > mask {
+				if !exhausted {
+					exhausted = true
+					i.emit(Event{Kind: EventSequenceExhausted})
+				}
 				continue
 			}
 		} else {
@@ -62,71 +122,130 @@ func (i *ID3) Generate() int64 {
 		if i.nodeBits > 0 {
 			now |= int64(i.node) << cBits
 		}
-		if atomic.CompareAndSwapInt64(&i.id, old, now) {
-			return now
+		if i.shardBits > 0 {
+			now |= int64(shard) << (cBits + i.nodeBits)
+		}
+		if i.tagBits > 0 {
+			now |= int64(i.tag) << (cBits + i.nodeBits + i.shardBits)
 		}
+		if atomic.CompareAndSwapInt64(cell, old, now) {
+			return now, nil
+		}
+		i.emit(Event{Kind: EventCASRetry})
 	}
 }
 
-func (i *ID3) getDelta() uint16 {
+func (i *ID3) getDelta() uint32 {
 	if i.randomDelta > 0 {
-		if de, err := rand.Int(rand.Reader, big.NewInt(int64(i.randomDelta))); err == nil {
-			return uint16(de.Int64() + 1)
-		}
+		return randomDelta(i.randSource, i.randomDelta)
 	}
 	return i.delta
 }
 
-func (i *ID3) SetDelta(d uint16) {
-	if d == 0 || d >= (1<<(MaxBits-i.bits-i.nodeBits)-1) {
+func (i *ID3) SetDelta(d uint32) {
+	if d == 0 || d >= (1<<i.seqBits()-1) {
 		panic("delta too large or invalid")
 	}
 	i.delta = d
 }
 
-func (i *ID3) GetDelta() uint16 {
+func (i *ID3) GetDelta() uint32 {
 	return i.delta
 }
 
-func (i *ID3) SetRandomDelta(r uint16) {
-	if r == 0 || r >= (1<<(MaxBits-i.bits-i.nodeBits)-1) {
+func (i *ID3) SetRandomDelta(r uint32) {
+	if r == 0 || r >= (1<<i.seqBits()-1) {
 		panic("random delta too large or invalid")
 	}
 	i.randomDelta = r
 }
 
-func (i *ID3) GetRandomDelta() uint16 {
+func (i *ID3) GetRandomDelta() uint32 {
 	return i.randomDelta
 }
 
-func (i *ID3) SetNode(node uint16, nodeBits uint8) {
-	if nodeBits < 2 || nodeBits > (MaxBits-i.bits-2) ||
+func (i *ID3) SetRandSource(src RandSource) {
+	if src == nil {
+		panic("rand source is nil")
+	}
+	i.randSource = src
+}
+
+func (i *ID3) GetRandSource() RandSource {
+	return i.randSource
+}
+
+func (i *ID3) SetNode(node uint32, nodeBits uint8) {
+	if nodeBits < 2 || nodeBits > (MaxBits-i.bits-i.tagBits-i.shardBits-2) ||
 		node > (1<<nodeBits-1) ||
-		i.delta >= (1<<(MaxBits-i.bits-nodeBits)-1) ||
-		i.randomDelta >= (1<<(MaxBits-i.bits-nodeBits)-1) {
+		i.delta >= (1<<(MaxBits-i.bits-i.tagBits-i.shardBits-nodeBits)-1) ||
+		i.randomDelta >= (1<<(MaxBits-i.bits-i.tagBits-i.shardBits-nodeBits)-1) {
 		panic("node or nodeBits is invalid")
 	}
-	i.node, i.nodeBits = node, nodeBits
+	i.node, i.nodeBits, i.workerBits = node, nodeBits, 0
 }
 
-func (i *ID3) GetNode() (node uint16, nodeBits uint8) {
+func (i *ID3) GetNode() (node uint32, nodeBits uint8) {
 	return i.node, i.nodeBits
 }
 
 func (i *ID3) SetBits(bits uint8) {
 	if bits < 42 || bits > 43 ||
-		i.delta >= (1<<(MaxBits-bits-i.nodeBits)-1) ||
-		i.randomDelta >= (1<<(MaxBits-bits-i.nodeBits)-1) {
+		i.delta >= (1<<(MaxBits-bits-i.tagBits-i.shardBits-i.nodeBits)-1) ||
+		i.randomDelta >= (1<<(MaxBits-bits-i.tagBits-i.shardBits-i.nodeBits)-1) {
 		panic("bits is invalid")
 	}
 	i.bits = bits
 }
 
+func (i *ID3) GetBits() uint8 {
+	return i.bits
+}
+
+func (i *ID3) SetMaxBacktrackWait(d time.Duration) {
+	if d < 0 {
+		panic("invalid maxBacktrackWait")
+	}
+	i.maxBacktrackWait = d
+}
+
+func (i *ID3) GetMaxBacktrackWait() time.Duration {
+	return i.maxBacktrackWait
+}
+
+func (i *ID3) SetNTPServer(s string) {
+	i.ntpServer = s
+	if s == "" {
+		i.ntpClient = nil
+		return
+	}
+	i.ntpClient = NewNTPClient(s)
+}
+
+func (i *ID3) GetNTPServer() string {
+	return i.ntpServer
+}
+
+func (i *ID3) SetNTPClient(c *NTPClient) {
+	i.ntpClient = c
+	i.ntpServer = ""
+	if c != nil {
+		i.ntpServer = strings.Join(c.GetServers(), ",")
+	}
+}
+
+func (i *ID3) GetNTPClient() *NTPClient {
+	return i.ntpClient
+}
+
 func NewID3() *ID3 {
 	return &ID3{
 		delta:            1,
 		bits:             42,
 		maxBacktrackWait: 3 * time.Second,
+		randSource:       newRandSource(),
+		hooks:            hooks{expiryWindow: DefaultExpiryWindow},
+		validator:        validator{validSkew: DefaultValidSkew},
 	}
 }
 
@@ -140,6 +259,21 @@ func GenID3() int64 {
 	return _id3.Generate()
 }
 
-func ResolveID3(id int64, oid *ID3) (timestamp int64, counter uint16) {
-	return id >> (MaxBits - oid.bits), uint16(id) & uint16((1<<(MaxBits-oid.bits-oid.nodeBits))-1)
+func ResolveID3(id int64, oid *ID3) (timestamp int64, counter uint32) {
+	return id >> (MaxBits - oid.bits), uint32(id) & uint32((1<<oid.seqBits())-1)
+}
+
+func (i *ID3) SetTimeSource(ts TimeSource) {
+	i.timeSource = ts
+}
+
+func (i *ID3) GetTimeSource() TimeSource {
+	return i.timeSource
+}
+
+func (i *ID3) now() time.Time {
+	if i.timeSource != nil {
+		return i.timeSource.Now()
+	}
+	return time.Now()
 }
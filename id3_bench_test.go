@@ -9,3 +9,35 @@ func BenchmarkID3_Generate(b *testing.B) {
 		id.Generate()
 	}
 }
+
+func BenchmarkID3_Generate_RandomDelta(b *testing.B) {
+	id := NewID3()
+	id.SetRandomDelta(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkID3_Generate_CryptoRandomDelta(b *testing.B) {
+	id := NewID3()
+	id.SetRandomDelta(16)
+	id.SetRandSource(CryptoRandSource)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkID3_GenerateForShard(b *testing.B) {
+	id := NewID3()
+	id.SetNode(0, 2)
+	id.SetShardBits(6)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.GenerateForShard(uint32(i) & 0x3f)
+	}
+}
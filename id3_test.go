@@ -106,10 +106,10 @@ func TestID3_Generate_43_increment(t *testing.T) {
 
 func TestID3_SetDelta(t *testing.T) {
 	id := NewID3()
-	delta := uint16(1 << 5)
+	delta := uint32(1 << 5)
 	id.SetDelta(delta)
 	var lt int64
-	var lc uint16
+	var lc uint32
 	for i := 0; i < 100000; i++ {
 		idV := id.Generate()
 		idt, idc := ResolveID3(idV, id)
@@ -135,10 +135,10 @@ func TestID3_SetDelta(t *testing.T) {
 
 func TestID3_SetRandomDelta(t *testing.T) {
 	id := NewID3()
-	delta := uint16(1 << 5)
+	delta := uint32(1 << 5)
 	id.SetRandomDelta(delta)
 	var lt int64
-	var lc uint16
+	var lc uint32
 
 	for i := 0; i < 100000; i++ {
 		idV := id.Generate()
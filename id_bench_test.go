@@ -9,3 +9,34 @@ func BenchmarkID_Generate(b *testing.B) {
 		id.Generate()
 	}
 }
+
+func BenchmarkID_Generate_RandomDelta(b *testing.B) {
+	id := NewID()
+	id.SetRandomDelta(16)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkID_Generate_CryptoRandomDelta(b *testing.B) {
+	id := NewID()
+	id.SetRandomDelta(16)
+	id.SetRandSource(CryptoRandSource)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkID_Generate_Parallel(b *testing.B) {
+	id := NewID()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id.Generate()
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package goid
+
+import (
+	"errors"
+	"math/bits"
+	"time"
+)
+
+var ErrNoLayout = errors.New("no id layout fits the requirements")
+
+// Layout describes how a generator splits an ID into timestamp, node and
+// sequence segments, from the most to the least significant bits. If
+// WorkerBits is set, the node segment is split further into a datacenter and
+// a worker of WorkerBits. If TagBits is set, a tag segment holding the entity
+// tag Tag sits between the timestamp and the node, and if ShardBits is set, a
+// shard segment sits between the tag and the node.
+type Layout struct {
+	Name       string
+	TimeBits   uint8
+	TagBits    uint8
+	Tag        uint32
+	ShardBits  uint8
+	NodeBits   uint8
+	WorkerBits uint8
+	SeqBits    uint8
+	Tick       time.Duration
+}
+
+// Parts is a decomposed ID; Timestamp counts ticks since the Unix epoch.
+// Datacenter and Worker are only set for layouts with WorkerBits.
+type Parts struct {
+	Timestamp  int64
+	Time       time.Time
+	Tag        uint32
+	Shard      uint32
+	Node       uint32
+	Datacenter uint32
+	Worker     uint32
+	Sequence   uint32
+}
+
+func (l Layout) decompose(id int64) Parts {
+	low := l.ShardBits + l.NodeBits + l.SeqBits
+	p := Parts{
+		Timestamp: id >> (l.TagBits + low),
+		Time:      tickTime(id>>(l.TagBits+low), l.Tick),
+		Tag:       uint32(id>>low) & (1<<l.TagBits - 1),
+		Shard:     uint32(id>>(l.NodeBits+l.SeqBits)) & (1<<l.ShardBits - 1),
+		Node:      uint32(id>>l.SeqBits) & (1<<l.NodeBits - 1),
+		Sequence:  uint32(id) & (1<<l.SeqBits - 1),
+	}
+	if l.WorkerBits > 0 {
+		p.Datacenter, p.Worker = p.Node>>l.WorkerBits, p.Node&(1<<l.WorkerBits-1)
+	}
+	return p
+}
+
+func (l Layout) TickDuration() time.Duration {
+	return l.Tick
+}
+
+// MaxTime is the last instant the timestamp segment can represent.
+func (l Layout) MaxTime() time.Time {
+	return tickTime(1<<l.TimeBits-1, l.Tick)
+}
+
+func (l Layout) MaxNodes() int {
+	return 1 << l.NodeBits
+}
+
+// MaxPerTick is the number of IDs a node can generate per tick with delta 1.
+func (l Layout) MaxPerTick() int {
+	return 1<<l.SeqBits - 1
+}
+
+func (l Layout) MaxPerSecond() int {
+	return l.MaxPerTick() * int(time.Second/l.Tick)
+}
+
+func (l Layout) RemainingLifetime(now time.Time) time.Duration {
+	return l.MaxTime().Sub(now)
+}
+
+func tickTime(ticks int64, tick time.Duration) time.Time {
+	if tick >= time.Second {
+		return time.Unix(ticks*int64(tick/time.Second), 0)
+	}
+	perSecond := int64(time.Second / tick)
+	return time.Unix(ticks/perSecond, ticks%perSecond*int64(tick))
+}
+
+var layouts = []Layout{
+	{Name: "id", TimeBits: 32, SeqBits: 21, Tick: time.Second},
+	{Name: "id2", TimeBits: 33, SeqBits: 20, Tick: time.Second},
+	{Name: "id3", TimeBits: 42, SeqBits: 11, Tick: time.Millisecond},
+	{Name: "id3-43", TimeBits: 43, SeqBits: 10, Tick: time.Millisecond},
+}
+
+// Requirements describe what a deployment needs from a layout.
+type Requirements struct {
+	Nodes     int
+	PerSecond int
+	Until     time.Time
+}
+
+// PlanLayout recommends the layout, with the smallest fitting node segment,
+// that offers the highest per-second throughput while supporting r.Nodes
+// nodes generating r.PerSecond IDs each until r.Until. It returns
+// ErrNoLayout if none fits.
+func PlanLayout(r Requirements) (Layout, error) {
+	var nodeBits uint8
+	if r.Nodes > 1 {
+		nodeBits = uint8(bits.Len(uint(r.Nodes - 1)))
+		if nodeBits < 2 {
+			nodeBits = 2
+		}
+	}
+	var best Layout
+	for _, l := range layouts {
+		if nodeBits+2 > l.SeqBits {
+			continue
+		}
+		l.NodeBits, l.SeqBits = nodeBits, l.SeqBits-nodeBits
+		if l.MaxPerSecond() < r.PerSecond || l.MaxTime().Before(r.Until) {
+			continue
+		}
+		if best.Tick == 0 || l.MaxPerSecond() > best.MaxPerSecond() ||
+			l.MaxPerSecond() == best.MaxPerSecond() && l.MaxTime().After(best.MaxTime()) {
+			best = l
+		}
+	}
+	if best.Tick == 0 {
+		return Layout{}, ErrNoLayout
+	}
+	return best, nil
+}
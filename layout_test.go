@@ -0,0 +1,78 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLayout(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	id3 := NewID3()
+	id3.SetBits(43)
+	tests := []struct {
+		layout     Layout
+		maxTime    string
+		maxPerTick int
+		perSecond  int
+	}{
+		{NewID().Layout(), "2106-02-07 14:28:15", 2097151, 2097151},
+		{NewID2().Layout(), "2242-03-16 20:56:31", 1048575, 1048575},
+		{NewID3().Layout(), "2109-05-15 15:35:11", 2047, 2047000},
+		{id3.Layout(), "2248-09-26 23:10:22", 1023, 1023000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout.Name, func(t *testing.T) {
+			if got := tt.layout.MaxTime().In(cst).Format(time.DateTime); got != tt.maxTime {
+				t.Errorf("MaxTime() = %v, want %v", got, tt.maxTime)
+			}
+			if got := tt.layout.MaxPerTick(); got != tt.maxPerTick {
+				t.Errorf("MaxPerTick() = %v, want %v", got, tt.maxPerTick)
+			}
+			if got := tt.layout.MaxPerSecond(); got != tt.perSecond {
+				t.Errorf("MaxPerSecond() = %v, want %v", got, tt.perSecond)
+			}
+			if got := tt.layout.MaxNodes(); got != 1 {
+				t.Errorf("MaxNodes() = %v, want 1", got)
+			}
+			now := time.Now()
+			if got := tt.layout.RemainingLifetime(now); got != tt.layout.MaxTime().Sub(now) {
+				t.Errorf("RemainingLifetime() = %v", got)
+			}
+		})
+	}
+
+	id := NewID()
+	id.SetNode(1, 10)
+	if l := id.Layout(); l.MaxNodes() != 1024 || l.MaxPerTick() != 2047 || l.TickDuration() != time.Second {
+		t.Errorf("unexpected layout %+v", l)
+	}
+}
+
+func TestPlanLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        Requirements
+		want     string
+		nodeBits uint8
+		wantErr  error
+	}{
+		{"single node", Requirements{Nodes: 1, PerSecond: 1000000, Until: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}, "id", 0, nil},
+		{"long lifetime", Requirements{Nodes: 1, PerSecond: 1000, Until: time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)}, "id2", 0, nil},
+		{"many nodes", Requirements{Nodes: 600, PerSecond: 1000, Until: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}, "id", 10, nil},
+		{"two nodes", Requirements{Nodes: 2, PerSecond: 1000, Until: time.Date(2240, 1, 1, 0, 0, 0, 0, time.UTC)}, "id2", 2, nil},
+		{"too fast", Requirements{Nodes: 2, PerSecond: 1000000, Until: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}, "", 0, ErrNoLayout},
+		{"too long", Requirements{Nodes: 1, Until: time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC)}, "", 0, ErrNoLayout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := PlanLayout(tt.r)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlanLayout() error = %v, want %v", err, tt.wantErr)
+			}
+			if l.Name != tt.want || l.NodeBits != tt.nodeBits {
+				t.Errorf("PlanLayout() = %+v, want %s with %d node bits", l, tt.want, tt.nodeBits)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package goid
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	DefaultLogInterval = time.Second
+)
+
+func (h *hooks) SetLogger(l *slog.Logger) {
+	h.logger = l
+}
+
+func (h *hooks) GetLogger() *slog.Logger {
+	return h.logger
+}
+
+// SetLogInterval sets the minimum interval between two log records of the
+// same event kind; records in between are dropped and counted as suppressed.
+func (h *hooks) SetLogInterval(d time.Duration) {
+	if d <= 0 {
+		panic("invalid logInterval")
+	}
+	h.logInterval = d
+}
+
+func (h *hooks) GetLogInterval() time.Duration {
+	if h.logInterval == 0 {
+		return DefaultLogInterval
+	}
+	return h.logInterval
+}
+
+func (h *hooks) log(e Event) {
+	if e.Kind == EventCASRetry || int(e.Kind) >= len(h.lastLog) {
+		return
+	}
+	now := time.Now().UnixNano()
+	last := h.lastLog[e.Kind].Load()
+	if last != 0 && time.Duration(now-last) < h.GetLogInterval() ||
+		!h.lastLog[e.Kind].CompareAndSwap(last, now) {
+		h.suppressed[e.Kind].Add(1)
+		return
+	}
+
+	level := slog.LevelWarn
+	var msg string
+	attrs := make([]slog.Attr, 0, 5)
+	switch e.Kind {
+	case EventSequenceExhausted:
+		msg = "goid: sequence exhausted, waiting for next tick"
+	case EventBacktrackWait:
+		msg = "goid: clock backtrack detected"
+		attrs = append(attrs, slog.Duration("behind", e.Behind))
+	case EventBacktrackResolved:
+		msg = "goid: clock backtrack resolved by waiting"
+		attrs = append(attrs, slog.Duration("waited", e.Waited))
+	case EventTimestampExpiring:
+		msg = "goid: timestamp segment nears overflow"
+		attrs = append(attrs, slog.Duration("remaining", e.Remaining))
+	case EventNTPFallback:
+		msg = "goid: ntp queried"
+		attrs = append(attrs,
+			slog.String("server", e.Server),
+			slog.Duration("behind", e.Behind),
+			slog.Duration("offset", e.Offset),
+			slog.Duration("latency", e.Latency),
+		)
+		if e.Err != nil {
+			level = slog.LevelError
+			attrs = append(attrs, slog.Any("error", e.Err))
+		}
+	}
+	if n := h.suppressed[e.Kind].Swap(0); n > 0 {
+		attrs = append(attrs, slog.Int64("suppressed", n))
+	}
+	h.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
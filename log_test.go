@@ -0,0 +1,95 @@
+package goid
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) records(t *testing.T) []map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var rs []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		r := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+func TestLogger_Backtrack(t *testing.T) {
+	b := &logBuffer{}
+	id := NewID3()
+	id.SetLogger(slog.New(slog.NewJSONHandler(b, nil)))
+	atomic.StoreInt64(&id.id, (time.Now().UnixMilli()+20)<<(MaxBits-id.bits))
+	id.Generate()
+
+	rs := b.records(t)
+	if len(rs) != 2 {
+		t.Fatalf("got %d records, want 2: %v", len(rs), rs)
+	}
+	if rs[0]["msg"] != "goid: clock backtrack detected" || rs[0]["level"] != "WARN" || rs[0]["behind"] == nil {
+		t.Errorf("unexpected record %v", rs[0])
+	}
+	if rs[1]["msg"] != "goid: clock backtrack resolved by waiting" || rs[1]["waited"] == nil {
+		t.Errorf("unexpected record %v", rs[1])
+	}
+}
+
+func TestLogger_RateLimit(t *testing.T) {
+	b := &logBuffer{}
+	id := NewID()
+	id.SetLogger(slog.New(slog.NewJSONHandler(b, nil)))
+	id.SetLogInterval(50 * time.Millisecond)
+	for i := 0; i < 1000; i++ {
+		id.emit(Event{Kind: EventSequenceExhausted})
+	}
+	if rs := b.records(t); len(rs) != 1 {
+		t.Fatalf("got %d records, want 1", len(rs))
+	}
+	time.Sleep(60 * time.Millisecond)
+	id.emit(Event{Kind: EventSequenceExhausted})
+	id.emit(Event{Kind: EventCASRetry})
+
+	rs := b.records(t)
+	if len(rs) != 2 {
+		t.Fatalf("got %d records, want 2", len(rs))
+	}
+	if rs[1]["msg"] != "goid: sequence exhausted, waiting for next tick" || rs[1]["suppressed"] != float64(999) {
+		t.Errorf("unexpected record %v", rs[1])
+	}
+}
+
+func TestLogger_NTP(t *testing.T) {
+	b := &logBuffer{}
+	id := NewID2()
+	id.SetLogger(slog.New(slog.NewJSONHandler(b, nil)))
+	id.emit(Event{Kind: EventNTPFallback, Server: "pool.ntp.org", Offset: time.Second, Latency: 20 * time.Millisecond})
+
+	rs := b.records(t)
+	if len(rs) != 1 || rs[0]["server"] != "pool.ntp.org" || rs[0]["offset"] != float64(time.Second) || rs[0]["latency"] == nil {
+		t.Errorf("unexpected records %v", rs)
+	}
+}
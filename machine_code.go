@@ -16,14 +16,21 @@ func GetLocalIP() (ip string, err error) {
 	if err != nil {
 		return
 	}
+	var ip6 string
 	for _, addr := range addrs {
 		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() {
 			if ipNet.IP.To4() != nil {
 				ip = ipNet.IP.String()
 				break
 			}
+			if ip6 == "" && ipNet.IP.IsGlobalUnicast() {
+				ip6 = ipNet.IP.String()
+			}
 		}
 	}
+	if ip == "" {
+		ip = ip6
+	}
 	if ip == "" {
 		err = errors.New("no non-loopback IP address found")
 	}
@@ -31,16 +38,32 @@ func GetLocalIP() (ip string, err error) {
 }
 
 func GenerateMachineCode(bits int8) (code int, err error) {
-	machineName, err := osHostname()
+	return GenerateMachineCodeFrom(MachineIDProviderFunc(hostnameIP), bits)
+}
+
+// GenerateMachineCodeFrom is GenerateMachineCode for the machine id of p.
+func GenerateMachineCodeFrom(p MachineIDProvider, bits int8) (code int, err error) {
+	id, err := p.MachineID()
 	if err != nil {
 		return
 	}
+	code = int(hashMachineID(id)) & int(1<<bits-1)
+	return
+}
+
+func hostnameIP() (string, error) {
+	machineName, err := osHostname()
+	if err != nil {
+		return "", err
+	}
 	ip, err := GetLocalIP()
 	if err != nil {
-		return
+		return "", err
 	}
-	combinedString := machineName + "_" + ip
-	hash := sha256.Sum256([]byte(combinedString))
-	code = int(binary.BigEndian.Uint64(hash[:])) & int(1<<bits-1)
-	return
+	return machineName + "_" + ip, nil
+}
+
+func hashMachineID(id string) uint64 {
+	hash := sha256.Sum256([]byte(id))
+	return binary.BigEndian.Uint64(hash[:])
 }
@@ -127,3 +127,35 @@ func TestGenerateMachineCode(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLocalIP_IPv6Only(t *testing.T) {
+	netInterfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{
+			&net.IPNet{IP: net.ParseIP("::1"), Mask: net.CIDRMask(128, 128)},
+			&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+			&net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(64, 128)},
+		}, nil
+	}
+	defer func() {
+		netInterfaceAddrs = net.InterfaceAddrs
+	}()
+
+	ip, err := GetLocalIP()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if ip != "2001:db8::2" {
+		t.Errorf("unexpected ip: got %v, want 2001:db8::2", ip)
+	}
+}
+
+func TestGenerateMachineCodeFrom(t *testing.T) {
+	p := MachineIDProviderFunc(func() (string, error) { return "1234_192.168.1.2", nil })
+	code, err := GenerateMachineCodeFrom(p, 8)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if code != 18 {
+		t.Errorf("unexpected code: got %v, want 18", code)
+	}
+}
@@ -0,0 +1,172 @@
+package goid
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var ErrNoMachineID = errors.New("no machine id found")
+
+var netInterfaces = net.Interfaces
+
+// MachineIDProvider returns a string identifying the current machine.
+type MachineIDProvider interface {
+	MachineID() (string, error)
+}
+
+type MachineIDProviderFunc func() (string, error)
+
+func (f MachineIDProviderFunc) MachineID() (string, error) {
+	return f()
+}
+
+// EnvProvider reads the machine id from the environment variable Name.
+type EnvProvider struct {
+	Name string
+}
+
+func (p EnvProvider) MachineID() (string, error) {
+	if v := strings.TrimSpace(os.Getenv(p.Name)); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%w: environment variable %s is empty", ErrNoMachineID, p.Name)
+}
+
+// FileProvider reads the machine id from the file at Path, by default
+// /etc/machine-id.
+type FileProvider struct {
+	Path string
+}
+
+func (p FileProvider) MachineID() (string, error) {
+	path := p.Path
+	if path == "" {
+		path = "/etc/machine-id"
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if v := strings.TrimSpace(string(b)); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%w: %s is empty", ErrNoMachineID, path)
+}
+
+// MACProvider returns the hardware address of the first interface that is
+// up and not a loopback.
+type MACProvider struct{}
+
+func (MACProvider) MachineID() (string, error) {
+	ifaces, err := netInterfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagLoopback == 0 && len(iface.HardwareAddr) > 0 {
+			return iface.HardwareAddr.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: no hardware address", ErrNoMachineID)
+}
+
+type HostnameProvider struct{}
+
+func (HostnameProvider) MachineID() (string, error) {
+	return osHostname()
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// ContainerIDProvider extracts the container id from Path, by default
+// /proc/self/cgroup. On cgroup v2 hosts /proc/self/mountinfo can be used.
+type ContainerIDProvider struct {
+	Path string
+}
+
+func (p ContainerIDProvider) MachineID() (string, error) {
+	path := p.Path
+	if path == "" {
+		path = "/proc/self/cgroup"
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if id := containerIDPattern.Find(b); id != nil {
+		return string(id), nil
+	}
+	return "", fmt.Errorf("%w: no container id in %s", ErrNoMachineID, path)
+}
+
+// IPProvider returns the first non-loopback IPv4 address, falling back to a
+// global unicast IPv6 address.
+type IPProvider struct{}
+
+func (IPProvider) MachineID() (string, error) {
+	return GetLocalIP()
+}
+
+// ChainProvider returns the machine id of the first provider that succeeds.
+type ChainProvider []MachineIDProvider
+
+func (c ChainProvider) MachineID() (string, error) {
+	errs := make([]error, 0, len(c)+1)
+	errs = append(errs, ErrNoMachineID)
+	for _, p := range c {
+		id, err := p.MachineID()
+		if err == nil {
+			return id, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// NodeProvider returns the node of the current machine for nodeBits bits.
+type NodeProvider interface {
+	Node(nodeBits uint8) (uint32, error)
+}
+
+type NodeProviderFunc func(nodeBits uint8) (uint32, error)
+
+func (f NodeProviderFunc) Node(nodeBits uint8) (uint32, error) {
+	return f(nodeBits)
+}
+
+// HashNode derives the node from a hash of the machine id of p, like
+// GenerateMachineCode. Different machines may end up with the same node.
+func HashNode(p MachineIDProvider) NodeProvider {
+	return NodeProviderFunc(func(nodeBits uint8) (uint32, error) {
+		id, err := p.MachineID()
+		if err != nil {
+			return 0, err
+		}
+		return uint32(hashMachineID(id) & (1<<nodeBits - 1)), nil
+	})
+}
+
+// SetNodeFrom sets the node of g to the one p returns for nodeBits. It
+// returns ErrNodeBits if g cannot have a node of nodeBits.
+func SetNodeFrom(g Generator, p NodeProvider, nodeBits uint8) error {
+	l := g.Layout()
+	seqBits := int(l.NodeBits) + int(l.SeqBits) - int(nodeBits)
+	if nodeBits < 2 || seqBits < 2 ||
+		g.GetDelta() >= 1<<seqBits-1 ||
+		g.GetRandomDelta() >= 1<<seqBits-1 {
+		return fmt.Errorf("%w: %d node bits for layout %s", ErrNodeBits, nodeBits, l.Name)
+	}
+	node, err := p.Node(nodeBits)
+	if err != nil {
+		return err
+	}
+	if node > 1<<nodeBits-1 {
+		return ErrNodeOutOfRange
+	}
+	g.SetNode(node, nodeBits)
+	return nil
+}
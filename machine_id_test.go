@@ -0,0 +1,128 @@
+package goid
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("GOID_TEST_MACHINE_ID", " abc \n")
+	if id, err := (EnvProvider{Name: "GOID_TEST_MACHINE_ID"}).MachineID(); err != nil || id != "abc" {
+		t.Errorf("MachineID() = %q, %v", id, err)
+	}
+	if _, err := (EnvProvider{Name: "GOID_TEST_MACHINE_ID_UNSET"}).MachineID(); !errors.Is(err, ErrNoMachineID) {
+		t.Errorf("MachineID() error = %v, want %v", err, ErrNoMachineID)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "machine-id")
+	if err := os.WriteFile(path, []byte("0123456789abcdef\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := (FileProvider{Path: path}).MachineID(); err != nil || id != "0123456789abcdef" {
+		t.Errorf("MachineID() = %q, %v", id, err)
+	}
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (FileProvider{Path: empty}).MachineID(); !errors.Is(err, ErrNoMachineID) {
+		t.Errorf("MachineID() error = %v, want %v", err, ErrNoMachineID)
+	}
+	if _, err := (FileProvider{Path: filepath.Join(dir, "missing")}).MachineID(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("MachineID() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestContainerIDProvider(t *testing.T) {
+	const cid = "8f3a4b6c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a"
+	path := filepath.Join(t.TempDir(), "cgroup")
+	content := "12:pids:/kubepods/burstable/pod1234/" + cid + "\n0::/\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := (ContainerIDProvider{Path: path}).MachineID(); err != nil || id != cid {
+		t.Errorf("MachineID() = %q, %v", id, err)
+	}
+	if err := os.WriteFile(path, []byte("0::/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (ContainerIDProvider{Path: path}).MachineID(); !errors.Is(err, ErrNoMachineID) {
+		t.Errorf("MachineID() error = %v, want %v", err, ErrNoMachineID)
+	}
+}
+
+func TestMACProvider(t *testing.T) {
+	defer func() { netInterfaces = net.Interfaces }()
+	mac, _ := net.ParseMAC("02:42:ac:11:00:02")
+	netInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{
+			{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+			{Name: "eth1", HardwareAddr: mac},
+			{Name: "eth0", Flags: net.FlagUp, HardwareAddr: mac},
+		}, nil
+	}
+	if id, err := (MACProvider{}).MachineID(); err != nil || id != "02:42:ac:11:00:02" {
+		t.Errorf("MachineID() = %q, %v", id, err)
+	}
+	netInterfaces = func() ([]net.Interface, error) {
+		return []net.Interface{{Name: "lo", Flags: net.FlagUp | net.FlagLoopback}}, nil
+	}
+	if _, err := (MACProvider{}).MachineID(); !errors.Is(err, ErrNoMachineID) {
+		t.Errorf("MachineID() error = %v, want %v", err, ErrNoMachineID)
+	}
+}
+
+func TestChainProvider(t *testing.T) {
+	defer func() { osHostname = os.Hostname }()
+	osHostname = func() (string, error) {
+		return "orders-7", nil
+	}
+	p := ChainProvider{
+		EnvProvider{Name: "GOID_TEST_MACHINE_ID_UNSET"},
+		FileProvider{Path: filepath.Join(t.TempDir(), "missing")},
+		HostnameProvider{},
+		IPProvider{},
+	}
+	if id, err := p.MachineID(); err != nil || id != "orders-7" {
+		t.Errorf("MachineID() = %q, %v", id, err)
+	}
+
+	_, err := ChainProvider{EnvProvider{Name: "GOID_TEST_MACHINE_ID_UNSET"}, FileProvider{Path: filepath.Join(t.TempDir(), "missing")}}.MachineID()
+	if !errors.Is(err, ErrNoMachineID) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("MachineID() error = %v", err)
+	}
+}
+
+func TestSetNodeFrom(t *testing.T) {
+	p := MachineIDProviderFunc(func() (string, error) { return "1234_192.168.1.2", nil })
+	for _, g := range []Generator{NewID(), NewID2(), NewID3()} {
+		if err := SetNodeFrom(g, HashNode(p), 8); err != nil {
+			t.Fatal(err)
+		}
+		if node, bits := g.GetNode(); node != 18 || bits != 8 {
+			t.Errorf("%T.GetNode() = %d, %d, want 18, 8", g, node, bits)
+		}
+	}
+
+	g := NewID()
+	if err := SetNodeFrom(g, HashNode(EnvProvider{Name: "GOID_TEST_MACHINE_ID_UNSET"}), 8); !errors.Is(err, ErrNoMachineID) {
+		t.Errorf("SetNodeFrom() error = %v, want %v", err, ErrNoMachineID)
+	}
+	if err := SetNodeFrom(g, NodeProviderFunc(func(uint8) (uint32, error) { return 256, nil }), 8); !errors.Is(err, ErrNodeOutOfRange) {
+		t.Errorf("SetNodeFrom() error = %v, want %v", err, ErrNodeOutOfRange)
+	}
+	for _, nodeBits := range []uint8{0, 1, 20} {
+		if err := SetNodeFrom(g, HashNode(p), nodeBits); !errors.Is(err, ErrNodeBits) {
+			t.Errorf("SetNodeFrom() with %d node bits error = %v, want %v", nodeBits, err, ErrNodeBits)
+		}
+	}
+	if err := SetNodeFrom(NewID3(), HashNode(p), 10); !errors.Is(err, ErrNodeBits) {
+		t.Errorf("SetNodeFrom() with 10 node bits on ID3 error = %v, want %v", err, ErrNodeBits)
+	}
+}
@@ -0,0 +1,159 @@
+package goid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+	count   atomic.Uint64
+	sum     atomic.Uint64 // float64 bits
+}
+
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]atomic.Uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i].Add(1)
+		}
+	}
+	h.count.Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+func (h *Histogram) Sum() float64 {
+	return math.Float64frombits(h.sum.Load())
+}
+
+func (h *Histogram) writePrometheus(w io.Writer, name, help string) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name); err != nil {
+		return err
+	}
+	for i, le := range h.buckets {
+		le := strconv.FormatFloat(le, 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, le, h.counts[i].Load()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n",
+		name, h.Count(), name, strconv.FormatFloat(h.Sum(), 'g', -1, 64), name, h.Count())
+	return err
+}
+
+// Metrics is an Observer that aggregates generation events into counters and
+// histograms. It can be scraped in the Prometheus text format through
+// WritePrometheus or ServeHTTP, and published as an expvar variable.
+type Metrics struct {
+	namespace         string
+	SequenceExhausted atomic.Uint64
+	CASRetries        atomic.Uint64
+	BacktrackWaits    atomic.Uint64
+	NTPFallbacks      atomic.Uint64
+	NTPErrors         atomic.Uint64
+	Backtrack         *Histogram
+	NTPLatency        *Histogram
+}
+
+func NewMetrics(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = "goid"
+	}
+	return &Metrics{
+		namespace:  namespace,
+		Backtrack:  NewHistogram(DefaultBuckets),
+		NTPLatency: NewHistogram(DefaultBuckets),
+	}
+}
+
+func (m *Metrics) Observe(e Event) {
+	switch e.Kind {
+	case EventSequenceExhausted:
+		m.SequenceExhausted.Add(1)
+	case EventCASRetry:
+		m.CASRetries.Add(1)
+	case EventBacktrackWait:
+		m.BacktrackWaits.Add(1)
+		m.Backtrack.Observe(e.Behind.Seconds())
+	case EventNTPFallback:
+		m.NTPFallbacks.Add(1)
+		if e.Err != nil {
+			m.NTPErrors.Add(1)
+		}
+		m.Backtrack.Observe(e.Behind.Seconds())
+		m.NTPLatency.Observe(e.Latency.Seconds())
+	}
+}
+
+func (m *Metrics) counters() []struct {
+	name, help string
+	value      uint64
+} {
+	return []struct {
+		name, help string
+		value      uint64
+	}{
+		{"sequence_exhausted_total", "Generate calls that waited for the next tick.", m.SequenceExhausted.Load()},
+		{"cas_retries_total", "Generated IDs lost to a concurrent compare-and-swap.", m.CASRetries.Load()},
+		{"backtrack_waits_total", "Generate calls that waited out a clock backtrack.", m.BacktrackWaits.Load()},
+		{"ntp_fallbacks_total", "Clock backtracks resolved through NTP.", m.NTPFallbacks.Load()},
+		{"ntp_errors_total", "NTP fallbacks that failed.", m.NTPErrors.Load()},
+	}
+}
+
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	for _, c := range m.counters() {
+		name := m.namespace + "_" + c.name
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, c.help, name, name, c.value); err != nil {
+			return err
+		}
+	}
+	if err := m.Backtrack.writePrometheus(w, m.namespace+"_backtrack_seconds", "Clock backtrack size."); err != nil {
+		return err
+	}
+	return m.NTPLatency.writePrometheus(w, m.namespace+"_ntp_latency_seconds", "NTP fallback query latency.")
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+// String renders the metrics as JSON, which makes Metrics an expvar.Var:
+//
+//	expvar.Publish("goid", metrics)
+func (m *Metrics) String() string {
+	v := make(map[string]interface{}, 7)
+	for _, c := range m.counters() {
+		v[c.name] = c.value
+	}
+	v["backtrack_seconds"] = map[string]interface{}{"count": m.Backtrack.Count(), "sum": m.Backtrack.Sum()}
+	v["ntp_latency_seconds"] = map[string]interface{}{"count": m.NTPLatency.Count(), "sum": m.NTPLatency.Sum()}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+var _ Observer = (*Metrics)(nil)
@@ -0,0 +1,85 @@
+package goid
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_WritePrometheus(t *testing.T) {
+	m := NewMetrics("")
+	m.Observe(Event{Kind: EventSequenceExhausted})
+	m.Observe(Event{Kind: EventCASRetry})
+	m.Observe(Event{Kind: EventCASRetry})
+	m.Observe(Event{Kind: EventBacktrackWait, Behind: 2 * time.Millisecond})
+	m.Observe(Event{Kind: EventNTPFallback, Behind: 4 * time.Second, Latency: 30 * time.Millisecond, Err: errors.New("timeout")})
+
+	var sb strings.Builder
+	if err := m.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# TYPE goid_cas_retries_total counter\ngoid_cas_retries_total 2\n",
+		"goid_sequence_exhausted_total 1\n",
+		"goid_backtrack_waits_total 1\n",
+		"goid_ntp_fallbacks_total 1\n",
+		"goid_ntp_errors_total 1\n",
+		"# TYPE goid_backtrack_seconds histogram\n",
+		"goid_backtrack_seconds_bucket{le=\"0.001\"} 0\n",
+		"goid_backtrack_seconds_bucket{le=\"0.005\"} 1\n",
+		"goid_backtrack_seconds_bucket{le=\"5\"} 2\n",
+		"goid_backtrack_seconds_bucket{le=\"+Inf\"} 2\n",
+		"goid_backtrack_seconds_sum 4.002\n",
+		"goid_ntp_latency_seconds_bucket{le=\"0.05\"} 1\n",
+		"goid_ntp_latency_seconds_count 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := NewMetrics("orders")
+	id := NewID3()
+	id.SetDelta(1000)
+	id.SetObserver(m)
+	for i := 0; i < 10; i++ {
+		id.Generate()
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type = %q", rec.Header().Get("Content-Type"))
+	}
+	if strings.Contains(rec.Body.String(), "orders_sequence_exhausted_total 0\n") {
+		t.Errorf("sequence exhaustion not counted:\n%s", rec.Body.String())
+	}
+}
+
+func TestMetrics_Expvar(t *testing.T) {
+	m := NewMetrics("")
+	m.Observe(Event{Kind: EventCASRetry})
+	m.Observe(Event{Kind: EventBacktrackWait, Behind: time.Second})
+	var ev expvar.Var = m
+
+	var v struct {
+		CASRetries uint64 `json:"cas_retries_total"`
+		Backtrack  struct {
+			Count uint64  `json:"count"`
+			Sum   float64 `json:"sum"`
+		} `json:"backtrack_seconds"`
+	}
+	if err := json.Unmarshal([]byte(ev.String()), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.CASRetries != 1 || v.Backtrack.Count != 1 || v.Backtrack.Sum != 1 {
+		t.Errorf("unexpected expvar value %+v", v)
+	}
+}
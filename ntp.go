@@ -0,0 +1,181 @@
+package goid
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/beevik/ntp"
+)
+
+const (
+	DefaultNTPTimeout = time.Second
+	DefaultNTPTTL     = time.Minute
+)
+
+var ErrNTPQuorum = errors.New("not enough valid ntp responses")
+
+var ntpQuery = ntp.QueryWithOptions
+
+// NTPClient measures the offset of the local clock against a set of NTP
+// servers. Every server is queried concurrently, the median offset of the
+// valid responses is used and cached for the TTL.
+type NTPClient struct {
+	mu       sync.Mutex
+	servers  []string
+	timeout  time.Duration
+	ttl      time.Duration
+	retries  int
+	quorum   int
+	offset   time.Duration
+	measured time.Time
+}
+
+func NewNTPClient(servers ...string) *NTPClient {
+	return &NTPClient{
+		servers: servers,
+		timeout: DefaultNTPTimeout,
+		ttl:     DefaultNTPTTL,
+		quorum:  1,
+	}
+}
+
+func (c *NTPClient) SetServers(servers ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.servers, c.measured = servers, time.Time{}
+}
+
+func (c *NTPClient) GetServers() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.servers...)
+}
+
+func (c *NTPClient) SetTimeout(d time.Duration) {
+	if d <= 0 {
+		panic("invalid ntp timeout")
+	}
+	c.timeout = d
+}
+
+func (c *NTPClient) GetTimeout() time.Duration {
+	return c.timeout
+}
+
+// SetTTL sets how long a measured offset is reused; 0 measures on every call.
+func (c *NTPClient) SetTTL(d time.Duration) {
+	if d < 0 {
+		panic("invalid ntp ttl")
+	}
+	c.ttl = d
+}
+
+func (c *NTPClient) GetTTL() time.Duration {
+	return c.ttl
+}
+
+// SetRetries sets how many more times a server is queried after a failure.
+func (c *NTPClient) SetRetries(n int) {
+	if n < 0 {
+		panic("invalid ntp retries")
+	}
+	c.retries = n
+}
+
+func (c *NTPClient) GetRetries() int {
+	return c.retries
+}
+
+// SetQuorum sets the minimum number of valid responses a measurement needs.
+func (c *NTPClient) SetQuorum(n int) {
+	if n < 1 {
+		panic("invalid ntp quorum")
+	}
+	c.quorum = n
+}
+
+func (c *NTPClient) GetQuorum() int {
+	return c.quorum
+}
+
+// Offset returns the cached clock offset, measuring it again once the TTL
+// has expired. Adding it to time.Now() gives the NTP time.
+func (c *NTPClient) Offset() (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.measured.IsZero() && time.Since(c.measured) < c.ttl {
+		return c.offset, nil
+	}
+	return c.measure()
+}
+
+// Measure queries the servers regardless of the cached offset.
+func (c *NTPClient) Measure() (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.measure()
+}
+
+func (c *NTPClient) Now() (time.Time, error) {
+	offset, err := c.Offset()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(offset), nil
+}
+
+func (c *NTPClient) measure() (time.Duration, error) {
+	type result struct {
+		offset time.Duration
+		err    error
+	}
+	results := make(chan result, len(c.servers))
+	for _, server := range c.servers {
+		go func(server string) {
+			offset, err := c.query(server)
+			results <- result{offset, err}
+		}(server)
+	}
+
+	offsets := make([]time.Duration, 0, len(c.servers))
+	var lastErr error
+	for range c.servers {
+		r := <-results
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		offsets = append(offsets, r.offset)
+	}
+	if len(offsets) == 0 || len(offsets) < c.quorum {
+		if lastErr == nil {
+			return 0, ErrNTPQuorum
+		}
+		return 0, fmt.Errorf("%w: %d of %d servers answered: %v", ErrNTPQuorum, len(offsets), len(c.servers), lastErr)
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	offset := offsets[len(offsets)/2]
+	if len(offsets)%2 == 0 {
+		offset = (offsets[len(offsets)/2-1] + offset) / 2
+	}
+	c.offset, c.measured = offset, time.Now()
+	return offset, nil
+}
+
+func (c *NTPClient) query(server string) (offset time.Duration, err error) {
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		var resp *ntp.Response
+		resp, err = ntpQuery(server, ntp.QueryOptions{Timeout: c.timeout})
+		if err == nil {
+			err = resp.Validate()
+		}
+		if err == nil {
+			return resp.ClockOffset, nil
+		}
+	}
+	return 0, fmt.Errorf("ntp server %s: %w", server, err)
+}
@@ -0,0 +1,177 @@
+package goid
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeNTPServer struct {
+	conn    net.PacketConn
+	offset  time.Duration
+	silent  atomic.Bool
+	queries atomic.Int64
+}
+
+func newFakeNTPServer(t *testing.T, offset time.Duration) *fakeNTPServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeNTPServer{conn: conn, offset: offset}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeNTPServer) Addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *fakeNTPServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.queries.Add(1)
+		if s.silent.Load() || n < 48 {
+			continue
+		}
+		now := toNTPTimestamp(time.Now().Add(s.offset))
+		resp := make([]byte, 48)
+		resp[0] = 0<<6 | 4<<3 | 4 // no leap warning, version 4, server mode
+		resp[1] = 1
+		copy(resp[12:16], "GOID")
+		binary.BigEndian.PutUint64(resp[16:], now)
+		copy(resp[24:32], buf[40:48])
+		binary.BigEndian.PutUint64(resp[32:], now)
+		binary.BigEndian.PutUint64(resp[40:], now)
+		_, _ = s.conn.WriteTo(resp, addr)
+	}
+}
+
+func toNTPTimestamp(t time.Time) uint64 {
+	const ntpEpochOffset = 2208988800
+	sec := uint64(t.Unix()) + ntpEpochOffset
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return sec<<32 | frac
+}
+
+func assertOffset(t *testing.T, got, want time.Duration) {
+	t.Helper()
+	if d := got - want; d < -50*time.Millisecond || d > 50*time.Millisecond {
+		t.Errorf("offset = %v, want ~%v", got, want)
+	}
+}
+
+func TestNTPClient_Offset(t *testing.T) {
+	s := newFakeNTPServer(t, 5*time.Second)
+	c := NewNTPClient(s.Addr())
+	offset, err := c.Offset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOffset(t, offset, 5*time.Second)
+
+	if _, err = c.Offset(); err != nil {
+		t.Fatal(err)
+	}
+	if q := s.queries.Load(); q != 1 {
+		t.Errorf("cached offset queried the server again, %d queries", q)
+	}
+	if _, err = c.Measure(); err != nil {
+		t.Fatal(err)
+	}
+	if q := s.queries.Load(); q != 2 {
+		t.Errorf("Measure did not query the server, %d queries", q)
+	}
+
+	now, err := c.Now()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOffset(t, time.Until(now), 5*time.Second)
+}
+
+func TestNTPClient_Median(t *testing.T) {
+	c := NewNTPClient(
+		newFakeNTPServer(t, time.Second).Addr(),
+		newFakeNTPServer(t, 2*time.Second).Addr(),
+		newFakeNTPServer(t, time.Hour).Addr(),
+	)
+	offset, err := c.Offset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOffset(t, offset, 2*time.Second)
+}
+
+func TestNTPClient_Quorum(t *testing.T) {
+	silent := newFakeNTPServer(t, 0)
+	silent.silent.Store(true)
+	c := NewNTPClient(silent.Addr(), newFakeNTPServer(t, time.Second).Addr())
+	c.SetTimeout(100 * time.Millisecond)
+	c.SetRetries(1)
+	offset, err := c.Offset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertOffset(t, offset, time.Second)
+	if q := silent.queries.Load(); q != 2 {
+		t.Errorf("silent server got %d queries, want 2", q)
+	}
+
+	c.SetQuorum(2)
+	if _, err = c.Measure(); !errors.Is(err, ErrNTPQuorum) {
+		t.Errorf("Measure() error = %v, want %v", err, ErrNTPQuorum)
+	}
+}
+
+func TestID3_NTPFallback(t *testing.T) {
+	s := newFakeNTPServer(t, 10*time.Second)
+	id := NewID3()
+	id.SetNTPClient(NewNTPClient(s.Addr()))
+	if id.GetNTPServer() != s.Addr() {
+		t.Errorf("GetNTPServer() = %q, want %q", id.GetNTPServer(), s.Addr())
+	}
+	ahead := time.Now().Add(8 * time.Second).UnixMilli()
+	atomic.StoreInt64(&id.id, ahead<<(MaxBits-id.bits))
+	ts, _ := ResolveID3(id.Generate(), id)
+	if ts < ahead {
+		t.Errorf("timestamp %d < %d", ts, ahead)
+	}
+}
+
+func TestID3_NTPFallback_StaleOffset(t *testing.T) {
+	s := newFakeNTPServer(t, 10*time.Second)
+	c := NewNTPClient(s.Addr())
+	// An offset cached before the local clock stepped back.
+	c.offset, c.measured = 0, time.Now()
+	id := NewID3()
+	id.SetNTPClient(c)
+	ahead := time.Now().Add(8 * time.Second).UnixMilli()
+	atomic.StoreInt64(&id.id, ahead<<(MaxBits-id.bits))
+	got, err := id.TryGenerate()
+	if err != nil {
+		t.Fatalf("TryGenerate() error = %v", err)
+	}
+	if ts, _ := ResolveID3(got, id); ts < ahead {
+		t.Errorf("timestamp %d < %d", ts, ahead)
+	}
+	if q := s.queries.Load(); q != 1 {
+		t.Fatalf("stale offset measured with %d queries, want 1", q)
+	}
+
+	// The local clock is still behind; the fresh offset is reused.
+	if _, err = id.TryGenerate(); err != nil {
+		t.Fatalf("TryGenerate() error = %v", err)
+	}
+	if q := s.queries.Load(); q != 1 {
+		t.Errorf("second fallback queried the server again, %d queries", q)
+	}
+}
@@ -0,0 +1,95 @@
+package goid
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+type EventKind uint8
+
+const (
+	// EventSequenceExhausted is emitted once per Generate call whose tick ran out of sequence numbers.
+	EventSequenceExhausted EventKind = iota + 1
+	// EventCASRetry is emitted every time a generated ID lost the compare-and-swap race.
+	EventCASRetry
+	// EventBacktrackWait is emitted once per Generate call that waits for a clock backtrack of Behind.
+	EventBacktrackWait
+	// EventNTPFallback is emitted when a backtrack longer than maxBacktrackWait falls back to NTP.
+	EventNTPFallback
+	// EventBacktrackResolved is emitted when a Generate call that waited for a backtrack went on after Waited.
+	EventBacktrackResolved
+	// EventTimestampExpiring is emitted once when the timestamp segment has less than the expiry window Remaining.
+	EventTimestampExpiring
+
+	lastEventKind = EventTimestampExpiring
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventSequenceExhausted:
+		return "sequence_exhausted"
+	case EventCASRetry:
+		return "cas_retry"
+	case EventBacktrackWait:
+		return "backtrack_wait"
+	case EventNTPFallback:
+		return "ntp_fallback"
+	case EventBacktrackResolved:
+		return "backtrack_resolved"
+	case EventTimestampExpiring:
+		return "timestamp_expiring"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Kind      EventKind
+	Behind    time.Duration
+	Server    string
+	Offset    time.Duration
+	Latency   time.Duration
+	Waited    time.Duration
+	Remaining time.Duration
+	Err       error
+}
+
+// Observer receives generation events. It is called synchronously from
+// Generate, so implementations must be cheap and safe for concurrent use.
+type Observer interface {
+	Observe(e Event)
+}
+
+type ObserverFunc func(e Event)
+
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+type hooks struct {
+	observer     Observer
+	logger       *slog.Logger
+	logInterval  time.Duration
+	lastLog      [lastEventKind + 1]atomic.Int64
+	suppressed   [lastEventKind + 1]atomic.Int64
+	expiryWindow time.Duration
+	expiryWarned atomic.Bool
+	halted       atomic.Pointer[error]
+}
+
+func (h *hooks) SetObserver(o Observer) {
+	h.observer = o
+}
+
+func (h *hooks) GetObserver() Observer {
+	return h.observer
+}
+
+func (h *hooks) emit(e Event) {
+	if h.observer != nil {
+		h.observer.Observe(e)
+	}
+	if h.logger != nil {
+		h.log(e)
+	}
+}
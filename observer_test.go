@@ -0,0 +1,81 @@
+package goid
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingObserver struct {
+	mu     sync.Mutex
+	events map[EventKind][]Event
+}
+
+func (c *countingObserver) Observe(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.events == nil {
+		c.events = make(map[EventKind][]Event)
+	}
+	c.events[e.Kind] = append(c.events[e.Kind], e)
+}
+
+func (c *countingObserver) count(k EventKind) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.events[k])
+}
+
+func TestObserver_SequenceExhausted(t *testing.T) {
+	o := &countingObserver{}
+	id := NewID3()
+	id.SetDelta(1000)
+	id.SetObserver(o)
+	for i := 0; i < 10; i++ {
+		id.Generate()
+	}
+	if o.count(EventSequenceExhausted) == 0 {
+		t.Errorf("no %v event", EventSequenceExhausted)
+	}
+}
+
+func TestObserver_BacktrackWait(t *testing.T) {
+	o := &countingObserver{}
+	id := NewID3()
+	id.SetObserver(o)
+	ahead := time.Now().UnixMilli() + 20
+	atomic.StoreInt64(&id.id, ahead<<(MaxBits-id.bits))
+	idV := id.Generate()
+	if ts, _ := ResolveID3(idV, id); ts < ahead {
+		t.Errorf("timestamp %d < %d", ts, ahead)
+	}
+	if o.count(EventBacktrackWait) != 1 {
+		t.Fatalf("got %d %v events, want 1", o.count(EventBacktrackWait), EventBacktrackWait)
+	}
+	if e := o.events[EventBacktrackWait][0]; e.Behind <= 0 || e.Behind > 20*time.Millisecond {
+		t.Errorf("Behind = %v", e.Behind)
+	}
+}
+
+func TestObserver_CASRetry(t *testing.T) {
+	var retries atomic.Int64
+	id := NewID()
+	id.SetObserver(ObserverFunc(func(e Event) {
+		if e.Kind == EventCASRetry {
+			retries.Add(1)
+		}
+	}))
+	wg := sync.WaitGroup{}
+	for j := 0; j < 16; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; k < 20000; k++ {
+				id.Generate()
+			}
+		}()
+	}
+	wg.Wait()
+	t.Logf("%d CAS retries", retries.Load())
+}
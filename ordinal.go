@@ -0,0 +1,65 @@
+package goid
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultOrdinalPattern matches the trailing number of a StatefulSet pod name
+// such as orders-3.
+const DefaultOrdinalPattern = `(\d+)$`
+
+// OrdinalNode uses an ordinal, e.g. the index of a Kubernetes StatefulSet pod,
+// as the node. The ordinal is the first submatch of Pattern (the whole match
+// if it has no group) in the hostname, or in the content of File if set, such
+// as a downward API volume exposing the pod name or a label.
+type OrdinalNode struct {
+	Pattern string
+	File    string
+}
+
+func (o OrdinalNode) Node(nodeBits uint8) (uint32, error) {
+	// No generator has a node segment outside 2 to 19 bits.
+	if nodeBits < 2 || nodeBits > 19 {
+		return 0, fmt.Errorf("%w: %d node bits", ErrNodeBits, nodeBits)
+	}
+	pattern := o.Pattern
+	if pattern == "" {
+		pattern = DefaultOrdinalPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	var s string
+	if o.File != "" {
+		b, err := os.ReadFile(o.File)
+		if err != nil {
+			return 0, err
+		}
+		s = strings.TrimSpace(string(b))
+	} else if s, err = osHostname(); err != nil {
+		return 0, err
+	}
+
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("%w in %q", ErrNoOrdinal, s)
+	}
+	match := m[0]
+	if len(m) > 1 {
+		match = m[1]
+	}
+	ordinal, err := strconv.ParseUint(match, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w in %q: %v", ErrNoOrdinal, s, err)
+	}
+	if ordinal > 1<<nodeBits-1 {
+		return 0, fmt.Errorf("%w: ordinal %d, node has %d bits", ErrNodeOutOfRange, ordinal, nodeBits)
+	}
+	return uint32(ordinal), nil
+}
@@ -0,0 +1,66 @@
+package goid
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOrdinalNode(t *testing.T) {
+	defer func() { osHostname = os.Hostname }()
+
+	tests := []struct {
+		hostname string
+		pattern  string
+		nodeBits uint8
+		want     uint32
+		err      error
+	}{
+		{"orders-0", "", 10, 0, nil},
+		{"orders-17", "", 10, 17, nil},
+		{"orders-1023", "", 10, 1023, nil},
+		{"orders-1024", "", 10, 0, ErrNodeOutOfRange},
+		{"orders", "", 10, 0, ErrNoOrdinal},
+		{"orders-3.orders.default.svc", `^orders-(\d+)\.`, 4, 3, nil},
+		{"orders-3.orders.default.svc", "", 4, 0, ErrNoOrdinal},
+		{"orders-99999999999", "", 10, 0, ErrNoOrdinal},
+		{"orders-0", "", 0, 0, ErrNodeBits},
+		{"orders-1", "", 1, 0, ErrNodeBits},
+		{"orders-1", "", 20, 0, ErrNodeBits},
+	}
+	for _, tt := range tests {
+		osHostname = func() (string, error) { return tt.hostname, nil }
+		got, err := OrdinalNode{Pattern: tt.pattern}.Node(tt.nodeBits)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Node(%q) error = %v, want %v", tt.hostname, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Node(%q) = %d, want %d", tt.hostname, got, tt.want)
+		}
+	}
+
+	if _, err := (OrdinalNode{Pattern: "("}).Node(10); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestOrdinalNode_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podname")
+	if err := os.WriteFile(path, []byte("orders-5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	id := NewID3()
+	if err := SetNodeFrom(id, OrdinalNode{File: path}, 5); err != nil {
+		t.Fatal(err)
+	}
+	if node, bits := id.GetNode(); node != 5 || bits != 5 {
+		t.Errorf("GetNode() = %d, %d, want 5, 5", node, bits)
+	}
+
+	if _, err := (OrdinalNode{File: filepath.Join(t.TempDir(), "missing")}).Node(5); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Node() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
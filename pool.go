@@ -0,0 +1,229 @@
+package goid
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const poolRetryInterval = 100 * time.Millisecond
+
+type pooledID struct {
+	id int64
+	at time.Time
+}
+
+// IDPool keeps a buffer of IDs pre-generated by a background goroutine.
+// Once the buffer drains to the low-water mark it is filled up again; if it
+// is empty, Get generates the ID directly instead of waiting, so IDs from a
+// pool are unique but not strictly increasing.
+type IDPool struct {
+	gen      interface{ Generate() int64 }
+	buf      chan pooledID
+	refill   chan struct{}
+	closed   chan struct{}
+	lowWater atomic.Int64
+	maxAge   atomic.Int64
+	mu       sync.Mutex
+	out      chan int64
+	done     bool
+	wg       sync.WaitGroup
+}
+
+func NewIDPool(gen interface{ Generate() int64 }, size int) *IDPool {
+	if size < 1 {
+		panic("invalid pool size")
+	}
+	p := &IDPool{
+		gen:    gen,
+		buf:    make(chan pooledID, size),
+		refill: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	p.lowWater.Store(int64(size / 2))
+	p.wg.Add(1)
+	go p.fill()
+	return p
+}
+
+func (p *IDPool) SetLowWater(n int) {
+	if n < 0 || n >= cap(p.buf) {
+		panic("invalid low water mark")
+	}
+	p.lowWater.Store(int64(n))
+}
+
+func (p *IDPool) GetLowWater() int {
+	return int(p.lowWater.Load())
+}
+
+// SetMaxAge drops pooled IDs generated more than d ago, e.g. a few ticks of
+// the wrapped generator, so IDs still roughly reflect their issue time; 0
+// keeps them forever.
+func (p *IDPool) SetMaxAge(d time.Duration) {
+	if d < 0 {
+		panic("invalid max age")
+	}
+	p.maxAge.Store(int64(d))
+}
+
+func (p *IDPool) GetMaxAge() time.Duration {
+	return time.Duration(p.maxAge.Load())
+}
+
+func (p *IDPool) Len() int {
+	return len(p.buf)
+}
+
+func (p *IDPool) Get() int64 {
+	id, err := p.TryGet()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// TryGet is Get returning the error of the wrapped generator instead of
+// panicking. Pooled IDs are dropped while the generator is halted, so none
+// are handed out after a node conflict.
+func (p *IDPool) TryGet() (int64, error) {
+	for {
+		if err := p.haltErr(); err != nil {
+			p.drain()
+			return 0, err
+		}
+		select {
+		case e := <-p.buf:
+			p.consumed()
+			if p.stale(e) {
+				continue
+			}
+			return e.id, nil
+		default:
+			p.consumed()
+			return p.generate()
+		}
+	}
+}
+
+// Chan returns a channel delivering pooled IDs; it is closed by Close.
+func (p *IDPool) Chan() <-chan int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.out == nil {
+		p.out = make(chan int64)
+		if p.done {
+			close(p.out)
+		} else {
+			p.wg.Add(1)
+			go p.forward()
+		}
+	}
+	return p.out
+}
+
+// Close stops the background goroutines. Get keeps working afterwards,
+// draining the buffer and then generating directly.
+func (p *IDPool) Close() {
+	p.mu.Lock()
+	if p.done {
+		p.mu.Unlock()
+		return
+	}
+	p.done = true
+	close(p.closed)
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *IDPool) fill() {
+	defer p.wg.Done()
+	for {
+		var retry <-chan time.Time
+		for len(p.buf) < cap(p.buf) {
+			id, err := p.generate()
+			if err != nil {
+				// Leave the error to TryGet and retry later, e.g. once a
+				// halted generator is resumed.
+				p.drain()
+				retry = time.After(poolRetryInterval)
+				break
+			}
+			select {
+			case p.buf <- pooledID{id: id, at: time.Now()}:
+			case <-p.closed:
+				return
+			}
+		}
+		select {
+		case <-p.refill:
+		case <-retry:
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+func (p *IDPool) forward() {
+	defer p.wg.Done()
+	defer close(p.out)
+	for {
+		var e pooledID
+		select {
+		case e = <-p.buf:
+			p.consumed()
+		case <-p.closed:
+			return
+		}
+		if p.stale(e) {
+			continue
+		}
+		if p.haltErr() != nil {
+			p.drain()
+			continue
+		}
+		select {
+		case p.out <- e.id:
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+func (p *IDPool) consumed() {
+	if int64(len(p.buf)) <= p.lowWater.Load() {
+		select {
+		case p.refill <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *IDPool) stale(e pooledID) bool {
+	maxAge := time.Duration(p.maxAge.Load())
+	return maxAge > 0 && time.Since(e.at) > maxAge
+}
+
+func (p *IDPool) generate() (int64, error) {
+	if g, ok := p.gen.(TryGenerator); ok {
+		return g.TryGenerate()
+	}
+	return p.gen.Generate(), nil
+}
+
+func (p *IDPool) haltErr() error {
+	if h, ok := p.gen.(interface{ haltErr() error }); ok {
+		return h.haltErr()
+	}
+	return nil
+}
+
+func (p *IDPool) drain() {
+	for {
+		select {
+		case <-p.buf:
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package goid
+
+import "testing"
+
+func BenchmarkIDPool_Get(b *testing.B) {
+	p := NewIDPool(NewID(), 4096)
+	defer p.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Get()
+	}
+}
@@ -0,0 +1,126 @@
+package goid
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingGenerator struct {
+	n atomic.Int64
+}
+
+func (g *countingGenerator) Generate() int64 {
+	return g.n.Add(1)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestIDPool_Get(t *testing.T) {
+	p := NewIDPool(NewID(), 1024)
+	defer p.Close()
+	waitFor(t, func() bool { return p.Len() == 1024 })
+
+	idArr := make(map[int64]struct{})
+	for i := 0; i < 100000; i++ {
+		idArr[p.Get()] = struct{}{}
+	}
+	if len(idArr) != 100000 {
+		t.Errorf("Duplicate ID generated, got %d", len(idArr))
+	}
+}
+
+func TestIDPool_LowWater(t *testing.T) {
+	g := &countingGenerator{}
+	p := NewIDPool(g, 10)
+	defer p.Close()
+	p.SetLowWater(2)
+	waitFor(t, func() bool { return p.Len() == 10 })
+
+	for i := 0; i < 7; i++ {
+		p.Get()
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := g.n.Load(); n != 10 {
+		t.Errorf("refilled above the low water mark, %d generated", n)
+	}
+	p.Get()
+	waitFor(t, func() bool { return p.Len() == 10 })
+	if n := g.n.Load(); n != 18 {
+		t.Errorf("generated %d ids, want 18", n)
+	}
+}
+
+func TestIDPool_MaxAge(t *testing.T) {
+	g := &countingGenerator{}
+	p := NewIDPool(g, 10)
+	waitFor(t, func() bool { return p.Len() == 10 })
+	p.Close()
+	p.SetMaxAge(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if idV := p.Get(); idV != 11 {
+		t.Errorf("Get() = %d, want a freshly generated 11", idV)
+	}
+	if p.Len() != 0 {
+		t.Errorf("stale ids left in pool: %d", p.Len())
+	}
+}
+
+func TestIDPool_Chan(t *testing.T) {
+	g := &countingGenerator{}
+	p := NewIDPool(g, 4)
+	ch := p.Chan()
+	for want := int64(1); want <= 20; want++ {
+		if idV := <-ch; idV != want {
+			t.Fatalf("<-Chan() = %d, want %d", idV, want)
+		}
+	}
+	p.Close()
+	for range ch {
+	}
+	if _, ok := <-p.Chan(); ok {
+		t.Errorf("Chan() not closed after Close")
+	}
+	if idV := p.Get(); idV == 0 {
+		t.Errorf("Get() after Close returned 0")
+	}
+}
+
+func TestIDPool_Halted(t *testing.T) {
+	g := NewID()
+	p := NewIDPool(g, 8)
+	defer p.Close()
+	waitFor(t, func() bool { return p.Len() == 8 })
+
+	g.Halt(ErrNodeConflict)
+	if _, err := p.TryGet(); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("TryGet() error = %v, want %v", err, ErrNodeConflict)
+	}
+	if p.Len() != 0 {
+		t.Errorf("%d pooled ids left after the generator halted", p.Len())
+	}
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrNodeConflict) {
+				t.Errorf("Get() panic = %v, want %v", err, ErrNodeConflict)
+			}
+		}()
+		p.Get()
+	}()
+
+	g.Resume()
+	if _, err := p.TryGet(); err != nil {
+		t.Errorf("TryGet() error = %v after Resume", err)
+	}
+	waitFor(t, func() bool { return p.Len() == 8 })
+}
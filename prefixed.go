@@ -0,0 +1,223 @@
+package goid
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// crockford is Crockford's base32 alphabet in lower case, ordered so that the
+// fixed-width encoding of IDs sorts like the IDs.
+const crockford = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// base32Width is the number of characters encoding a 64-bit ID.
+const base32Width = 13
+
+var (
+	ErrPrefix    = errors.New("id prefix does not match")
+	ErrBadFormat = errors.New("malformed id")
+)
+
+var crockfordValues = func() (v [256]int8) {
+	for c := range v {
+		v[c] = -1
+	}
+	for n := 0; n < len(crockford); n++ {
+		v[crockford[n]] = int8(n)
+		v[strings.ToUpper(crockford[n : n+1])[0]] = int8(n)
+	}
+	for _, c := range "oO" {
+		v[c] = 0
+	}
+	for _, c := range "iIlL" {
+		v[c] = 1
+	}
+	return
+}()
+
+func encodeBase32(id int64) string {
+	var b [base32Width]byte
+	u := uint64(id)
+	for n := base32Width - 1; n >= 0; n-- {
+		b[n] = crockford[u&31]
+		u >>= 5
+	}
+	return string(b[:])
+}
+
+// decodeBase32 decodes case-insensitively, reading o as 0 and i and l as 1.
+func decodeBase32(s string) (int64, error) {
+	if len(s) != base32Width {
+		return 0, ErrBadFormat
+	}
+	var u uint64
+	for n := 0; n < len(s); n++ {
+		v := crockfordValues[s[n]]
+		if v < 0 || n == 0 && v > 15 {
+			return 0, ErrBadFormat
+		}
+		u = u<<5 | uint64(v)
+	}
+	return int64(u), nil
+}
+
+var (
+	prefixesMu sync.RWMutex
+	prefixes   = make(map[string]*Prefixed)
+)
+
+// Prefixed mints Stripe-style string IDs such as ord_01h5v3x0k2q8m: the
+// prefix naming the entity, an underscore and the IDs of gen in a sortable
+// base32 encoding.
+type Prefixed struct {
+	prefix string
+	gen    interface{ Generate() int64 }
+}
+
+// NewPrefixed registers prefix, lower case letters and digits starting with a
+// letter, for the IDs of gen. A prefix can only be registered once.
+func NewPrefixed(prefix string, gen interface{ Generate() int64 }) *Prefixed {
+	if !validPrefix(prefix) {
+		panic("invalid id prefix " + prefix)
+	}
+	prefixesMu.Lock()
+	defer prefixesMu.Unlock()
+	if _, ok := prefixes[prefix]; ok {
+		panic("id prefix " + prefix + " already registered")
+	}
+	p := &Prefixed{prefix: prefix, gen: gen}
+	prefixes[prefix] = p
+	return p
+}
+
+// LookupPrefixed returns the Prefixed registered for prefix.
+func LookupPrefixed(prefix string) (*Prefixed, bool) {
+	prefixesMu.RLock()
+	defer prefixesMu.RUnlock()
+	p, ok := prefixes[prefix]
+	return p, ok
+}
+
+func validPrefix(prefix string) bool {
+	if prefix == "" || prefix[0] < 'a' || prefix[0] > 'z' {
+		return false
+	}
+	for n := 1; n < len(prefix); n++ {
+		if c := prefix[n]; (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Prefixed) Prefix() string {
+	return p.prefix
+}
+
+func (p *Prefixed) Generate() PrefixedID {
+	return PrefixedID{Prefix: p.prefix, ID: p.gen.Generate()}
+}
+
+func (p *Prefixed) Format(id int64) PrefixedID {
+	return PrefixedID{Prefix: p.prefix, ID: id}
+}
+
+// Parse parses s, failing with ErrPrefix if it has another prefix.
+func (p *Prefixed) Parse(s string) (PrefixedID, error) {
+	id, err := parsePrefixed(s)
+	if err != nil {
+		return PrefixedID{}, err
+	}
+	if id.Prefix != p.prefix {
+		return PrefixedID{}, fmt.Errorf("%w: %q is not a %s id", ErrPrefix, s, p.prefix)
+	}
+	return id, nil
+}
+
+// PrefixedID is a numeric ID with its entity prefix. It marshals to and from
+// its string form in JSON and SQL; if Prefix is set before unmarshaling, the
+// input must have that prefix, otherwise any registered prefix is accepted.
+type PrefixedID struct {
+	Prefix string
+	ID     int64
+}
+
+// ParsePrefixed parses s, which must have a registered prefix.
+func ParsePrefixed(s string) (PrefixedID, error) {
+	id, err := parsePrefixed(s)
+	if err != nil {
+		return PrefixedID{}, err
+	}
+	if _, ok := LookupPrefixed(id.Prefix); !ok {
+		return PrefixedID{}, fmt.Errorf("%w: unknown prefix in %q", ErrPrefix, s)
+	}
+	return id, nil
+}
+
+func parsePrefixed(s string) (PrefixedID, error) {
+	prefix, enc, ok := strings.Cut(s, "_")
+	if !ok || !validPrefix(prefix) {
+		return PrefixedID{}, fmt.Errorf("%w: %q", ErrBadFormat, s)
+	}
+	id, err := decodeBase32(enc)
+	if err != nil {
+		return PrefixedID{}, fmt.Errorf("%w: %q", err, s)
+	}
+	return PrefixedID{Prefix: prefix, ID: id}, nil
+}
+
+func (id PrefixedID) String() string {
+	return id.Prefix + "_" + encodeBase32(id.ID)
+}
+
+func (id PrefixedID) IsZero() bool {
+	return id == PrefixedID{}
+}
+
+func (id PrefixedID) MarshalText() ([]byte, error) {
+	if id.Prefix == "" {
+		return nil, fmt.Errorf("%w: no prefix", ErrBadFormat)
+	}
+	return []byte(id.String()), nil
+}
+
+func (id *PrefixedID) UnmarshalText(b []byte) error {
+	var parsed PrefixedID
+	var err error
+	if id.Prefix != "" {
+		parsed, err = parsePrefixed(string(b))
+		if err == nil && parsed.Prefix != id.Prefix {
+			err = fmt.Errorf("%w: %q is not a %s id", ErrPrefix, b, id.Prefix)
+		}
+	} else {
+		parsed, err = ParsePrefixed(string(b))
+	}
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Value stores the string form; the zero PrefixedID is stored as NULL.
+func (id PrefixedID) Value() (driver.Value, error) {
+	if id.IsZero() {
+		return nil, nil
+	}
+	return id.String(), nil
+}
+
+func (id *PrefixedID) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*id = PrefixedID{}
+		return nil
+	case string:
+		return id.UnmarshalText([]byte(v))
+	case []byte:
+		return id.UnmarshalText(v)
+	}
+	return fmt.Errorf("cannot scan %T into PrefixedID", src)
+}
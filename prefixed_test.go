@@ -0,0 +1,139 @@
+package goid
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"testing/quick"
+)
+
+var (
+	testOrders = NewPrefixed("ord", NewID3())
+	testUsers  = NewPrefixed("usr", NewID())
+)
+
+func TestBase32(t *testing.T) {
+	f := func(id int64) bool {
+		got, err := decodeBase32(encodeBase32(id))
+		return err == nil && got == id
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	ids := []int64{0, 1, 31, 32, 1 << 40, 1<<53 - 1, math.MaxInt64}
+	encoded := make([]string, len(ids))
+	for n, id := range ids {
+		encoded[n] = encodeBase32(id)
+		if len(encoded[n]) != base32Width {
+			t.Errorf("encodeBase32(%d) = %q, want %d characters", id, encoded[n], base32Width)
+		}
+	}
+	if !sort.StringsAreSorted(encoded) {
+		t.Errorf("encodings are not sorted: %v", encoded)
+	}
+
+	if id, err := decodeBase32("0000000000O1L"); err != nil || id != 1<<5|1 {
+		t.Errorf("decodeBase32() = %d, %v", id, err)
+	}
+	for _, s := range []string{"", "000000000001", "00000000000001", "000000000000u", "g000000000000"} {
+		if _, err := decodeBase32(s); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("decodeBase32(%q) error = %v, want %v", s, err, ErrBadFormat)
+		}
+	}
+}
+
+func TestPrefixed(t *testing.T) {
+	id := testOrders.Generate()
+	s := id.String()
+	if s[:4] != "ord_" || len(s) != 4+base32Width {
+		t.Errorf("String() = %q", s)
+	}
+	if parsed, err := testOrders.Parse(s); err != nil || parsed != id {
+		t.Errorf("Parse(%q) = %v, %v", s, parsed, err)
+	}
+	if parsed, err := ParsePrefixed(s); err != nil || parsed != id {
+		t.Errorf("ParsePrefixed(%q) = %v, %v", s, parsed, err)
+	}
+	if _, err := testUsers.Parse(s); !errors.Is(err, ErrPrefix) {
+		t.Errorf("Parse() error = %v, want %v", err, ErrPrefix)
+	}
+	if _, err := ParsePrefixed("abc_" + s[4:]); !errors.Is(err, ErrPrefix) {
+		t.Errorf("ParsePrefixed() error = %v, want %v", err, ErrPrefix)
+	}
+	for _, bad := range []string{"ord", "ord_", "_" + s[4:], "Ord_" + s[4:], "ord_" + s[5:]} {
+		if _, err := ParsePrefixed(bad); !errors.Is(err, ErrBadFormat) {
+			t.Errorf("ParsePrefixed(%q) error = %v, want %v", bad, err, ErrBadFormat)
+		}
+	}
+
+	if p, ok := LookupPrefixed("usr"); !ok || p != testUsers {
+		t.Errorf("LookupPrefixed(usr) = %v, %v", p, ok)
+	}
+	for _, prefix := range []string{"ord", "", "1ab", "a-b", "A"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewPrefixed(%q) did not panic", prefix)
+				}
+			}()
+			NewPrefixed(prefix, NewID())
+		}()
+	}
+}
+
+func TestPrefixedID_JSON(t *testing.T) {
+	type order struct {
+		ID    PrefixedID  `json:"id"`
+		Buyer PrefixedID  `json:"buyer"`
+		Ref   *PrefixedID `json:"ref,omitempty"`
+	}
+	in := order{ID: testOrders.Generate(), Buyer: testUsers.Generate()}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"` + in.ID.String() + `","buyer":"` + in.Buyer.String() + `"}`
+	if string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+	var out order
+	if err = json.Unmarshal(b, &out); err != nil || out != in {
+		t.Errorf("Unmarshal() = %+v, %v", out, err)
+	}
+
+	typed := order{ID: PrefixedID{Prefix: "ord"}, Buyer: PrefixedID{Prefix: "usr"}}
+	swapped := `{"id":"` + in.Buyer.String() + `","buyer":"` + in.ID.String() + `"}`
+	if err = json.Unmarshal([]byte(swapped), &typed); !errors.Is(err, ErrPrefix) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrPrefix)
+	}
+	if _, err = json.Marshal(order{}); !errors.Is(err, ErrBadFormat) {
+		t.Errorf("Marshal() of a zero id error = %v, want %v", err, ErrBadFormat)
+	}
+}
+
+func TestPrefixedID_SQL(t *testing.T) {
+	id := testUsers.Generate()
+	v, err := id.Value()
+	if err != nil || v != id.String() {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+	if v, err = (PrefixedID{}).Value(); err != nil || v != nil {
+		t.Errorf("Value() of a zero id = %v, %v", v, err)
+	}
+
+	var scanned PrefixedID
+	for _, src := range []any{id.String(), []byte(id.String())} {
+		if err = scanned.Scan(src); err != nil || scanned != id {
+			t.Errorf("Scan(%v) = %v, %v", src, scanned, err)
+		}
+	}
+	if err = scanned.Scan(nil); err != nil || !scanned.IsZero() {
+		t.Errorf("Scan(nil) = %v, %v", scanned, err)
+	}
+	if err = scanned.Scan(int64(1)); err == nil {
+		t.Error("expected an error scanning an int64")
+	}
+}
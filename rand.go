@@ -0,0 +1,62 @@
+package goid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// RandSource is a source of uniformly distributed random numbers used for
+// random deltas. Its method set matches math/rand/v2.Source. Implementations
+// must be safe for concurrent use.
+type RandSource interface {
+	Uint64() uint64
+}
+
+type fastRandSource struct {
+	state atomic.Uint64
+}
+
+// NewFastRandSource returns a lock-free, allocation-free splitmix64 source.
+// It is not suitable where the deltas must be unpredictable.
+func NewFastRandSource(seed uint64) RandSource {
+	s := &fastRandSource{}
+	s.state.Store(seed)
+	return s
+}
+
+func (s *fastRandSource) Uint64() uint64 {
+	z := s.state.Add(0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+type cryptoRandSource struct{}
+
+// CryptoRandSource reads crypto/rand and panics if it fails.
+var CryptoRandSource RandSource = cryptoRandSource{}
+
+func (cryptoRandSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func newRandSource() RandSource {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return NewFastRandSource(uint64(time.Now().UnixNano()))
+	}
+	return NewFastRandSource(binary.LittleEndian.Uint64(b[:]))
+}
+
+// randomDelta returns a delta in [1, n].
+func randomDelta(src RandSource, n uint32) uint32 {
+	hi, _ := bits.Mul64(src.Uint64(), uint64(n))
+	return uint32(hi) + 1
+}
@@ -0,0 +1,19 @@
+package goid
+
+import "testing"
+
+func BenchmarkRandomDelta_Fast(b *testing.B) {
+	src := NewFastRandSource(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		randomDelta(src, 1024)
+	}
+}
+
+func BenchmarkRandomDelta_Crypto(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		randomDelta(CryptoRandSource, 1024)
+	}
+}
@@ -0,0 +1,68 @@
+package goid
+
+import (
+	"testing"
+)
+
+type constRandSource uint64
+
+func (c constRandSource) Uint64() uint64 {
+	return uint64(c)
+}
+
+func TestRandomDelta(t *testing.T) {
+	for _, src := range []RandSource{NewFastRandSource(1), CryptoRandSource} {
+		seen := make(map[uint32]bool)
+		for i := 0; i < 10000; i++ {
+			d := randomDelta(src, 8)
+			if d < 1 || d > 8 {
+				t.Fatalf("randomDelta() = %d, want [1, 8]", d)
+			}
+			seen[d] = true
+		}
+		if len(seen) != 8 {
+			t.Errorf("%T produced %d distinct deltas, want 8", src, len(seen))
+		}
+	}
+	if d := randomDelta(constRandSource(0), 8); d != 1 {
+		t.Errorf("randomDelta(0) = %d, want 1", d)
+	}
+	if d := randomDelta(constRandSource(^uint64(0)), 8); d != 8 {
+		t.Errorf("randomDelta(max) = %d, want 8", d)
+	}
+}
+
+func TestFastRandSource_Seed(t *testing.T) {
+	a, b := NewFastRandSource(42), NewFastRandSource(42)
+	for i := 0; i < 100; i++ {
+		if a.Uint64() != b.Uint64() {
+			t.Fatalf("same seed produced different sequences")
+		}
+	}
+}
+
+func TestSetRandSource(t *testing.T) {
+	id := NewID()
+	id.SetRandomDelta(1000)
+	id.SetRandSource(constRandSource(^uint64(0)))
+	for i := 0; i < 100; i++ {
+		if _, c := ResolveID(id.Generate(), id); c%1000 != 0 {
+			t.Fatalf("counter = %d, want a multiple of 1000", c)
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(1000, func() { id.Generate() }); allocs != 0 {
+		t.Errorf("Generate allocates %v times per id", allocs)
+	}
+	id.SetRandSource(NewFastRandSource(1))
+	if allocs := testing.AllocsPerRun(1000, func() { id.Generate() }); allocs != 0 {
+		t.Errorf("Generate with fast rand source allocates %v times per id", allocs)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetRandSource(nil) should panic")
+		}
+	}()
+	id.SetRandSource(nil)
+}
@@ -0,0 +1,76 @@
+package goid
+
+import "time"
+
+func timeTicks(t time.Time, tick time.Duration) int64 {
+	if tick >= time.Second {
+		return t.Unix() / int64(tick/time.Second)
+	}
+	return t.Unix()*int64(time.Second/tick) + int64(t.Nanosecond())/int64(tick)
+}
+
+func (l Layout) clampTicks(t time.Time) int64 {
+	ticks := timeTicks(t, l.Tick)
+	if ticks < 0 {
+		return 0
+	}
+	if max := int64(1)<<l.TimeBits - 1; ticks > max {
+		return max
+	}
+	return ticks
+}
+
+// MinIDAt returns the smallest ID any node can generate on any shard in the
+// tick of t with the layout's tag.
+func (l Layout) MinIDAt(t time.Time) int64 {
+	low := l.ShardBits + l.NodeBits + l.SeqBits
+	return l.clampTicks(t)<<(l.TagBits+low) | int64(l.Tag)<<low
+}
+
+// MaxIDAt returns the largest ID any node can generate on any shard in the
+// tick of t with the layout's tag.
+func (l Layout) MaxIDAt(t time.Time) int64 {
+	return l.MinIDAt(t) | (1<<(l.ShardBits+l.NodeBits+l.SeqBits) - 1)
+}
+
+// Range returns the bounds of the IDs generated from the tick of from to the
+// tick of to, inclusive, for use as WHERE id BETWEEN min AND max.
+func (l Layout) Range(from, to time.Time) (min, max int64) {
+	return l.MinIDAt(from), l.MaxIDAt(to)
+}
+
+func (i *ID) MinIDAt(t time.Time) int64 {
+	return i.Layout().MinIDAt(t)
+}
+
+func (i *ID) MaxIDAt(t time.Time) int64 {
+	return i.Layout().MaxIDAt(t)
+}
+
+func (i *ID) Range(from, to time.Time) (min, max int64) {
+	return i.Layout().Range(from, to)
+}
+
+func (i *ID2) MinIDAt(t time.Time) int64 {
+	return i.Layout().MinIDAt(t)
+}
+
+func (i *ID2) MaxIDAt(t time.Time) int64 {
+	return i.Layout().MaxIDAt(t)
+}
+
+func (i *ID2) Range(from, to time.Time) (min, max int64) {
+	return i.Layout().Range(from, to)
+}
+
+func (i *ID3) MinIDAt(t time.Time) int64 {
+	return i.Layout().MinIDAt(t)
+}
+
+func (i *ID3) MaxIDAt(t time.Time) int64 {
+	return i.Layout().MaxIDAt(t)
+}
+
+func (i *ID3) Range(from, to time.Time) (min, max int64) {
+	return i.Layout().Range(from, to)
+}
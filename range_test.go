@@ -0,0 +1,55 @@
+package goid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	id3 := NewID3()
+	id3.SetNode(5, 4)
+	id := NewID()
+	id.SetNode(1, 10)
+	for _, g := range []interface {
+		Generator
+		MinIDAt(t time.Time) int64
+		MaxIDAt(t time.Time) int64
+		Range(from, to time.Time) (int64, int64)
+	}{NewID(), NewID2(), id, id3} {
+		before := time.Now()
+		idV := g.Generate()
+		after := time.Now()
+		if min, max := g.Range(before, after); idV < min || idV > max {
+			t.Errorf("%T: id %d not in [%d, %d]", g, idV, min, max)
+		}
+
+		p := g.Decompose(idV)
+		if min := g.MinIDAt(p.Time); min >= idV || g.Decompose(min).Timestamp != p.Timestamp {
+			t.Errorf("%T: MinIDAt() = %d for id %d", g, min, idV)
+		}
+		if max := g.MaxIDAt(p.Time); max <= idV || g.Decompose(max).Timestamp != p.Timestamp {
+			t.Errorf("%T: MaxIDAt() = %d for id %d", g, max, idV)
+		}
+		tick := g.Layout().Tick
+		if g.MaxIDAt(p.Time)+1 != g.MinIDAt(p.Time.Add(tick)) {
+			t.Errorf("%T: ranges of adjacent ticks are not contiguous", g)
+		}
+	}
+}
+
+func TestRange_Bounds(t *testing.T) {
+	id := NewID3()
+	if got := id.MinIDAt(time.Unix(-100, 0)); got != 0 {
+		t.Errorf("MinIDAt(before epoch) = %d, want 0", got)
+	}
+	if got := id.MaxIDAt(time.Date(3000, 1, 1, 0, 0, 0, 0, time.UTC)); got != 1<<MaxBits-1 {
+		t.Errorf("MaxIDAt(after max time) = %d, want %d", got, int64(1<<MaxBits-1))
+	}
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	if got, want := id.MinIDAt(ts), ts.UnixMilli()<<11; got != want {
+		t.Errorf("MinIDAt() = %d, want %d", got, want)
+	}
+	if got, want := NewID().MaxIDAt(ts), (ts.Unix()+1)<<21-1; got != want {
+		t.Errorf("MaxIDAt() = %d, want %d", got, want)
+	}
+}
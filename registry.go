@@ -0,0 +1,272 @@
+package goid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const DefaultRegistryTTL = 30 * time.Second
+
+type registryEntry struct {
+	PID      int       `json:"pid"`
+	Host     string    `json:"host"`
+	Instance string    `json:"instance"`
+	Expires  time.Time `json:"expires"`
+}
+
+// NodeRegistry reserves nodes through heartbeat files node-<n>.lock in a
+// directory shared by the instances, e.g. on an NFS or local volume. A node
+// whose file has not been refreshed within the TTL is considered free.
+type NodeRegistry struct {
+	dir      string
+	ttl      time.Duration
+	instance string
+	mu       sync.Mutex
+	held     map[uint32]Generator
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewNodeRegistry(dir string, ttl time.Duration) *NodeRegistry {
+	if ttl <= 0 {
+		panic("invalid registry ttl")
+	}
+	return &NodeRegistry{
+		dir:      dir,
+		ttl:      ttl,
+		instance: newInstanceID(),
+		held:     make(map[uint32]Generator),
+	}
+}
+
+func (r *NodeRegistry) Instance() string {
+	return r.instance
+}
+
+// SetNode reserves node and sets it on g, releasing the node g held before.
+// It fails with ErrNodeConflict if another live instance holds the node. The
+// reservation is refreshed in the background until Release or Close; if it is
+// lost to another instance, g is halted with ErrNodeConflict if it is a
+// Halter.
+func (r *NodeRegistry) SetNode(g Generator, node uint32, nodeBits uint8) error {
+	if node > 1<<nodeBits-1 {
+		return ErrNodeOutOfRange
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if held, ok := r.held[node]; ok && held != g {
+		return fmt.Errorf("%w: node %d is held by another generator", ErrNodeConflict, node)
+	}
+	if err := r.acquire(node); err != nil {
+		return err
+	}
+	defer func() {
+		// g.SetNode panics on an invalid nodeBits; do not leave the node
+		// reserved behind it.
+		if _, ok := r.held[node]; !ok {
+			os.Remove(r.path(node))
+		}
+	}()
+	g.SetNode(node, nodeBits)
+	for n, held := range r.held {
+		if held == g && n != node {
+			r.release(n)
+		}
+	}
+	r.held[node] = g
+	if r.stop == nil {
+		r.stop, r.done = make(chan struct{}), make(chan struct{})
+		go r.heartbeat(r.stop, r.done)
+	}
+	return nil
+}
+
+// Release gives up node.
+func (r *NodeRegistry) Release(node uint32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.release(node)
+}
+
+// Close stops the heartbeat and releases every node.
+func (r *NodeRegistry) Close() error {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	var errs []error
+	for node := range r.held {
+		errs = append(errs, r.release(node))
+	}
+	r.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return errors.Join(errs...)
+}
+
+func (r *NodeRegistry) path(node uint32) string {
+	return filepath.Join(r.dir, fmt.Sprintf("node-%d.lock", node))
+}
+
+func (r *NodeRegistry) entry() registryEntry {
+	host, _ := osHostname()
+	return registryEntry{
+		PID:      os.Getpid(),
+		Host:     host,
+		Instance: r.instance,
+		Expires:  time.Now().Add(r.ttl),
+	}
+}
+
+func (r *NodeRegistry) read(node uint32) (registryEntry, error) {
+	var e registryEntry
+	b, err := os.ReadFile(r.path(node))
+	if err != nil {
+		return e, err
+	}
+	// A file that cannot be decoded is being written or corrupt; it is stale
+	// once it is older than the TTL.
+	if err = json.Unmarshal(b, &e); err != nil {
+		if info, err := os.Stat(r.path(node)); err == nil {
+			e.Expires = info.ModTime().Add(r.ttl)
+		}
+	}
+	return e, nil
+}
+
+func (r *NodeRegistry) acquire(node uint32) error {
+	path := r.path(node)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			err = json.NewEncoder(f).Encode(r.entry())
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+			}
+			return err
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+
+		e, err := r.read(node)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if e.Instance == r.instance {
+			return r.write(node)
+		}
+		if time.Now().Before(e.Expires) {
+			return fmt.Errorf("%w: node %d is held by pid %d on %s until %s", ErrNodeConflict, node, e.PID, e.Host, e.Expires.Format(time.RFC3339))
+		}
+		// Take over the stale entry. Renaming it away succeeds for only one
+		// of several instances racing for the node.
+		stale := path + "." + r.instance
+		if err = os.Rename(path, stale); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		os.Remove(stale)
+	}
+}
+
+// write refreshes the entry of a held node in place, so that the file never
+// disappears while the node is held. A takeover replaces the file instead of
+// writing to it, which leaves our write on the old file.
+func (r *NodeRegistry) write(node uint32) error {
+	path := r.path(node)
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: node %d was taken over", ErrNodeConflict, node)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var e registryEntry
+	if b, err := io.ReadAll(f); err == nil {
+		json.Unmarshal(b, &e)
+	}
+	if e.Instance != r.instance {
+		return fmt.Errorf("%w: node %d was taken over by pid %d on %s", ErrNodeConflict, node, e.PID, e.Host)
+	}
+
+	b, err := json.Marshal(r.entry())
+	if err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(b, 0); err != nil {
+		return err
+	}
+	if err = f.Truncate(int64(len(b))); err != nil {
+		return err
+	}
+	held, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if cur, err := os.Stat(path); err != nil || !os.SameFile(held, cur) {
+		return fmt.Errorf("%w: node %d was taken over", ErrNodeConflict, node)
+	}
+	return nil
+}
+
+func (r *NodeRegistry) release(node uint32) error {
+	if _, ok := r.held[node]; !ok {
+		return nil
+	}
+	delete(r.held, node)
+	e, err := r.read(node)
+	if err != nil || e.Instance != r.instance {
+		return nil
+	}
+	return os.Remove(r.path(node))
+}
+
+func (r *NodeRegistry) heartbeat(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.refresh()
+		}
+	}
+}
+
+func (r *NodeRegistry) refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for node, g := range r.held {
+		e, err := r.read(node)
+		switch {
+		case err == nil && e.Instance != r.instance:
+			err = fmt.Errorf("%w: node %d was taken over by pid %d on %s", ErrNodeConflict, node, e.PID, e.Host)
+		case err == nil:
+			err = r.write(node)
+		case errors.Is(err, os.ErrNotExist):
+			err = r.acquire(node)
+		}
+		if errors.Is(err, ErrNodeConflict) {
+			delete(r.held, node)
+			if h, ok := g.(Halter); ok {
+				h.Halt(err)
+			}
+		}
+	}
+}
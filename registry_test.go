@@ -0,0 +1,185 @@
+package goid
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeRegistryEntry(t *testing.T, dir string, node uint32, e registryEntry) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the file like an instance taking the node over.
+	path := filepath.Join(dir, fmt.Sprintf("node-%d.lock", node))
+	if err = os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodeRegistry(t *testing.T) {
+	dir := t.TempDir()
+	r1 := NewNodeRegistry(dir, DefaultRegistryTTL)
+	defer r1.Close()
+	r2 := NewNodeRegistry(dir, DefaultRegistryTTL)
+	defer r2.Close()
+
+	g1, g2 := NewID(), NewID2()
+	if err := r1.SetNode(g1, 5, 8); err != nil {
+		t.Fatal(err)
+	}
+	if node, bits := g1.GetNode(); node != 5 || bits != 8 {
+		t.Errorf("GetNode() = %d, %d, want 5, 8", node, bits)
+	}
+	if err := r2.SetNode(g2, 5, 8); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("SetNode() error = %v, want %v", err, ErrNodeConflict)
+	}
+	if err := r1.SetNode(NewID3(), 5, 8); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("SetNode() error = %v, want %v", err, ErrNodeConflict)
+	}
+	if err := r1.SetNode(g1, 5, 8); err != nil {
+		t.Errorf("SetNode() of the held node error = %v", err)
+	}
+	if err := r2.SetNode(g2, 256, 8); !errors.Is(err, ErrNodeOutOfRange) {
+		t.Errorf("SetNode() error = %v, want %v", err, ErrNodeOutOfRange)
+	}
+
+	// Moving g1 to another node frees its previous one.
+	if err := r1.SetNode(g1, 6, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.SetNode(g2, 5, 8); err != nil {
+		t.Errorf("SetNode() error = %v after the node was released", err)
+	}
+
+	if err := r1.Release(6); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "node-6.lock")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("released node file still exists: %v", err)
+	}
+
+	if err := r2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "node-5.lock")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("node file still exists after Close: %v", err)
+	}
+}
+
+func TestNodeRegistry_RefreshRace(t *testing.T) {
+	dir := t.TempDir()
+	r := NewNodeRegistry(dir, time.Hour)
+	defer r.Close()
+	g := NewID()
+	if err := r.SetNode(g, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is refreshed in place, it never leaves the path.
+	path := filepath.Join(dir, "node-1.lock")
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.refresh()
+	if after, err := os.Stat(path); err != nil || !os.SameFile(before, after) {
+		t.Errorf("refresh replaced the node file: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				r.refresh()
+			}
+		}
+	}()
+	for n := 0; n < 2000; n++ {
+		if err := NewNodeRegistry(dir, time.Hour).acquire(1); !errors.Is(err, ErrNodeConflict) {
+			t.Errorf("acquire() of a live node during a refresh error = %v, want %v", err, ErrNodeConflict)
+			break
+		}
+	}
+	close(stop)
+	<-done
+	if _, err := g.TryGenerate(); err != nil {
+		t.Errorf("TryGenerate() error = %v after racing refreshes", err)
+	}
+}
+
+func TestNodeRegistry_InvalidNodeBits(t *testing.T) {
+	dir := t.TempDir()
+	r := NewNodeRegistry(dir, DefaultRegistryTTL)
+	defer r.Close()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("SetNode() with 1 node bit did not panic")
+			}
+		}()
+		r.SetNode(NewID(), 1, 1)
+	}()
+	if _, err := os.Stat(filepath.Join(dir, "node-1.lock")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("node file left behind by a failed SetNode: %v", err)
+	}
+	if err := NewNodeRegistry(dir, DefaultRegistryTTL).SetNode(NewID(), 1, 4); err != nil {
+		t.Errorf("SetNode() error = %v after a failed SetNode", err)
+	}
+}
+
+func TestNodeRegistry_Stale(t *testing.T) {
+	dir := t.TempDir()
+	writeRegistryEntry(t, dir, 3, registryEntry{PID: 1, Host: "old", Instance: "old", Expires: time.Now().Add(-time.Second)})
+	writeRegistryEntry(t, dir, 4, registryEntry{PID: 1, Host: "live", Instance: "live", Expires: time.Now().Add(time.Minute)})
+
+	r := NewNodeRegistry(dir, DefaultRegistryTTL)
+	defer r.Close()
+	if err := r.SetNode(NewID3(), 3, 4); err != nil {
+		t.Errorf("SetNode() of a stale node error = %v", err)
+	}
+	if err := r.SetNode(NewID3(), 4, 4); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("SetNode() error = %v, want %v", err, ErrNodeConflict)
+	}
+}
+
+func TestNodeRegistry_Heartbeat(t *testing.T) {
+	dir := t.TempDir()
+	r := NewNodeRegistry(dir, 30*time.Millisecond)
+	defer r.Close()
+	g := NewID()
+	if err := r.SetNode(g, 1, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if err := NewNodeRegistry(dir, time.Second).SetNode(NewID(), 1, 4); !errors.Is(err, ErrNodeConflict) {
+		t.Errorf("SetNode() error = %v, want %v while the heartbeat runs", err, ErrNodeConflict)
+	}
+
+	// Losing the node to another instance halts the generator.
+	writeRegistryEntry(t, dir, 1, registryEntry{PID: 1, Host: "other", Instance: "other", Expires: time.Now().Add(time.Minute)})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := g.TryGenerate(); errors.Is(err, ErrNodeConflict) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("generator not halted after losing its node")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,121 @@
+package goid
+
+import "sync"
+
+func checkShardBits(shardBits uint8, seqBits int, delta, randomDelta uint32) {
+	if shardBits > 16 || seqBits < 2 ||
+		delta >= (1<<seqBits-1) ||
+		randomDelta >= (1<<seqBits-1) {
+		panic("shardBits is invalid")
+	}
+}
+
+func shardCell(shards *sync.Map, shard uint32) *int64 {
+	cell, ok := shards.Load(shard)
+	if !ok {
+		cell, _ = shards.LoadOrStore(shard, new(int64))
+	}
+	return cell.(*int64)
+}
+
+// SetShardBits adds a shard segment of shardBits, taken from the sequence,
+// between the tag and the node; 0 shardBits removes it. Generate puts IDs on
+// shard 0.
+func (i *ID) SetShardBits(shardBits uint8) {
+	checkShardBits(shardBits, 21-int(i.tagBits)-int(shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.shardBits = shardBits
+}
+
+func (i *ID) GetShardBits() uint8 {
+	return i.shardBits
+}
+
+// GenerateForShard generates an ID carrying shard, e.g. the logical shard of
+// the parent row, in the shard segment next to the node, with a sequence of
+// its own per shard. Any node can generate for any shard; shard 0 shares the
+// sequence of Generate.
+func (i *ID) GenerateForShard(shard uint32) int64 {
+	id, err := i.TryGenerateForShard(shard)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (i *ID) TryGenerateForShard(shard uint32) (int64, error) {
+	if i.shardBits == 0 || shard > 1<<i.shardBits-1 {
+		return 0, ErrShardOutOfRange
+	}
+	if shard == 0 {
+		return i.generate(&i.id, 0)
+	}
+	return i.generate(shardCell(&i.shards, shard), shard)
+}
+
+// ShardOf extracts the shard of id, so that child rows can be given IDs on
+// the shard of their parent.
+func (i *ID) ShardOf(id int64) uint32 {
+	return i.Decompose(id).Shard
+}
+
+func (i *ID2) SetShardBits(shardBits uint8) {
+	checkShardBits(shardBits, 20-int(i.tagBits)-int(shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.shardBits = shardBits
+}
+
+func (i *ID2) GetShardBits() uint8 {
+	return i.shardBits
+}
+
+func (i *ID2) GenerateForShard(shard uint32) int64 {
+	id, err := i.TryGenerateForShard(shard)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (i *ID2) TryGenerateForShard(shard uint32) (int64, error) {
+	if i.shardBits == 0 || shard > 1<<i.shardBits-1 {
+		return 0, ErrShardOutOfRange
+	}
+	if shard == 0 {
+		return i.generate(&i.id, 0)
+	}
+	return i.generate(shardCell(&i.shards, shard), shard)
+}
+
+func (i *ID2) ShardOf(id int64) uint32 {
+	return i.Decompose(id).Shard
+}
+
+func (i *ID3) SetShardBits(shardBits uint8) {
+	checkShardBits(shardBits, int(MaxBits)-int(i.bits)-int(i.tagBits)-int(shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.shardBits = shardBits
+}
+
+func (i *ID3) GetShardBits() uint8 {
+	return i.shardBits
+}
+
+func (i *ID3) GenerateForShard(shard uint32) int64 {
+	id, err := i.TryGenerateForShard(shard)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (i *ID3) TryGenerateForShard(shard uint32) (int64, error) {
+	if i.shardBits == 0 || shard > 1<<i.shardBits-1 {
+		return 0, ErrShardOutOfRange
+	}
+	if shard == 0 {
+		return i.generate(&i.id, 0)
+	}
+	return i.generate(shardCell(&i.shards, shard), shard)
+}
+
+func (i *ID3) ShardOf(id int64) uint32 {
+	return i.Decompose(id).Shard
+}
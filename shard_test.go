@@ -0,0 +1,105 @@
+package goid
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type shardGenerator interface {
+	Generator
+	SetShardBits(shardBits uint8)
+	GenerateForShard(shard uint32) int64
+	TryGenerateForShard(shard uint32) (int64, error)
+	ShardOf(id int64) uint32
+}
+
+func TestGenerateForShard(t *testing.T) {
+	for _, g := range []shardGenerator{NewID(), NewID2(), NewID3()} {
+		g.SetNode(3, 2)
+		g.SetShardBits(6)
+		parent := g.GenerateForShard(42)
+		if shard := g.ShardOf(parent); shard != 42 {
+			t.Errorf("%T.ShardOf() = %d, want 42", g, shard)
+		}
+		if node := g.Decompose(parent).Node; node != 3 {
+			t.Errorf("%T shard id node = %d, want 3", g, node)
+		}
+		child := g.GenerateForShard(g.ShardOf(parent))
+		if child <= parent || g.ShardOf(child) != 42 {
+			t.Errorf("%T child %d of %d not on shard 42", g, child, parent)
+		}
+		if shard := g.ShardOf(g.Generate()); shard != 0 {
+			t.Errorf("%T.ShardOf(Generate()) = %d, want 0", g, shard)
+		}
+		if _, err := g.TryGenerateForShard(64); !errors.Is(err, ErrShardOutOfRange) {
+			t.Errorf("%T.TryGenerateForShard(64) error = %v, want %v", g, err, ErrShardOutOfRange)
+		}
+	}
+
+	if _, err := NewID().TryGenerateForShard(0); !errors.Is(err, ErrShardOutOfRange) {
+		t.Errorf("TryGenerateForShard() without shard bits error = %v, want %v", err, ErrShardOutOfRange)
+	}
+}
+
+func TestGenerateForShard_AcrossNodes(t *testing.T) {
+	g1, g2 := NewID(), NewID()
+	g1.SetNode(1, 4)
+	g1.SetShardBits(4)
+	g2.SetNode(2, 4)
+	g2.SetShardBits(4)
+	seen := make(map[int64]struct{})
+	for k := 0; k < 1000; k++ {
+		for _, id := range []int64{g1.GenerateForShard(2), g2.GenerateForShard(2), g2.Generate(), g1.Generate()} {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("duplicate id %d", id)
+			}
+			seen[id] = struct{}{}
+		}
+	}
+	id := g2.GenerateForShard(2)
+	if p := g2.Decompose(id); p.Shard != 2 || p.Node != 2 {
+		t.Errorf("Decompose() shard, node = %d, %d, want 2, 2", p.Shard, p.Node)
+	}
+}
+
+func TestGenerateForShard_Unique(t *testing.T) {
+	g := NewID3()
+	g.SetNode(1, 2)
+	g.SetShardBits(2)
+	const goroutines, perGoroutine = 8, 2000
+	var mu sync.Mutex
+	seen := make(map[int64]struct{}, goroutines*perGoroutine)
+	var wg sync.WaitGroup
+	for n := 0; n < goroutines; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			ids := make([]int64, 0, perGoroutine)
+			for k := 0; k < perGoroutine; k++ {
+				shard := uint32(k % 4)
+				var id int64
+				if shard == 0 {
+					id = g.Generate()
+				} else {
+					id = g.GenerateForShard(shard)
+				}
+				if g.ShardOf(id) != shard {
+					t.Errorf("ShardOf(%d) = %d, want %d", id, g.ShardOf(id), shard)
+					return
+				}
+				ids = append(ids, id)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, id := range ids {
+				if _, ok := seen[id]; ok {
+					t.Errorf("duplicate id %d", id)
+					return
+				}
+				seen[id] = struct{}{}
+			}
+		}(n)
+	}
+	wg.Wait()
+}
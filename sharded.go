@@ -0,0 +1,92 @@
+package goid
+
+import (
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedID spreads generation over several ID sub-generators so that
+// concurrent callers rarely contend on the same compare-and-swap. The low
+// shardBits of the node segment hold the shard number, so IDs stay globally
+// unique, but they are only monotonic per shard, not globally. Each shard
+// gets 1/Shards() of the per-second sequence space.
+type ShardedID struct {
+	shards    []*ID
+	shardBits uint8
+	node      uint32
+	nodeBits  uint8
+	next      atomic.Uint32
+	pool      sync.Pool
+}
+
+// NewShardedID creates 1<<shardBits shards; 0 picks enough shards for
+// GOMAXPROCS.
+func NewShardedID(shardBits uint8) *ShardedID {
+	if shardBits == 0 {
+		shardBits = uint8(bits.Len(uint(runtime.GOMAXPROCS(0) - 1)))
+		if shardBits < 2 {
+			shardBits = 2
+		}
+	}
+	if shardBits < 2 || shardBits > 19 {
+		panic("shardBits is invalid")
+	}
+	s := &ShardedID{
+		shards:    make([]*ID, 1<<shardBits),
+		shardBits: shardBits,
+	}
+	for n := range s.shards {
+		s.shards[n] = NewID()
+		s.shards[n].SetNode(uint32(n), shardBits)
+	}
+	// sync.Pool keeps a per-P cache, so a goroutine usually gets back the
+	// shard last used on its P.
+	s.pool.New = func() interface{} {
+		return s.shards[s.next.Add(1)&uint32(len(s.shards)-1)]
+	}
+	return s
+}
+
+func (s *ShardedID) Generate() int64 {
+	id := s.pool.Get().(*ID)
+	v := id.Generate()
+	s.pool.Put(id)
+	return v
+}
+
+func (s *ShardedID) Shards() int {
+	return len(s.shards)
+}
+
+func (s *ShardedID) Shard(n int) *ID {
+	return s.shards[n]
+}
+
+// SetNode sets the machine node, which is stored above the shard bits.
+func (s *ShardedID) SetNode(node uint32, nodeBits uint8) {
+	if nodeBits+s.shardBits > 19 || node > (1<<nodeBits-1) {
+		panic("node or nodeBits is invalid")
+	}
+	for n, id := range s.shards {
+		id.SetNode(node<<s.shardBits|uint32(n), nodeBits+s.shardBits)
+	}
+	s.node, s.nodeBits = node, nodeBits
+}
+
+func (s *ShardedID) GetNode() (node uint32, nodeBits uint8) {
+	return s.node, s.nodeBits
+}
+
+func (s *ShardedID) SetDelta(d uint32) {
+	for _, id := range s.shards {
+		id.SetDelta(d)
+	}
+}
+
+func (s *ShardedID) SetRandomDelta(r uint32) {
+	for _, id := range s.shards {
+		id.SetRandomDelta(r)
+	}
+}
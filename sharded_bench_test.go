@@ -0,0 +1,21 @@
+package goid
+
+import "testing"
+
+func BenchmarkShardedID_Generate(b *testing.B) {
+	id := NewShardedID(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id.Generate()
+	}
+}
+
+func BenchmarkShardedID_Generate_Parallel(b *testing.B) {
+	id := NewShardedID(0)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id.Generate()
+		}
+	})
+}
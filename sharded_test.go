@@ -0,0 +1,66 @@
+package goid
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestShardedID_Generate_duplicate(t *testing.T) {
+	ll := 500000
+	id := NewShardedID(3)
+	id.SetNode(5, 4)
+	results := make([][]int64, 32)
+	wg := sync.WaitGroup{}
+	for j := range results {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			for k := 0; k < ll/len(results); k++ {
+				results[j] = append(results[j], id.Generate())
+			}
+		}(j)
+	}
+	wg.Wait()
+
+	idArr := make(map[int64]struct{}, ll)
+	cBits := 21 - 4 - 3
+	for _, ids := range results {
+		for _, idV := range ids {
+			idArr[idV] = struct{}{}
+			node := uint32(idV>>cBits) & (1<<7 - 1)
+			if node>>3 != 5 {
+				t.Fatalf("node %d of id %d does not carry machine node 5", node, idV)
+			}
+		}
+	}
+	if len(idArr) != ll {
+		t.Errorf("Duplicate ID generated, want %d, got %d", ll, len(idArr))
+	}
+}
+
+func TestShardedID_Generate_increment(t *testing.T) {
+	id := NewShardedID(2)
+	for n := 0; n < id.Shards(); n++ {
+		var latestID int64
+		for i := 0; i < 100000; i++ {
+			idV := id.Shard(n).Generate()
+			if idV <= latestID {
+				t.Fatalf("shard %d: id (%d) <= latestID (%d) ", n, idV, latestID)
+			}
+			latestID = idV
+		}
+	}
+}
+
+func TestNewShardedID(t *testing.T) {
+	id := NewShardedID(0)
+	if id.Shards() < 4 {
+		t.Errorf("Shards() = %d, want at least 4", id.Shards())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("SetNode with too many bits should panic")
+		}
+	}()
+	NewShardedID(10).SetNode(1, 10)
+}
@@ -0,0 +1,64 @@
+package goid
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+var interfaceAddrs = func(name string) ([]net.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return iface.Addrs()
+}
+
+// SubnetNode derives the node from the position of the host's address inside
+// CIDR, e.g. the low 10 bits of an address in a /22. Unlike HashNode, hosts in
+// the same subnet never share a node. If Interface is set, only the addresses
+// of that interface are considered.
+type SubnetNode struct {
+	CIDR      string
+	Interface string
+}
+
+func (s SubnetNode) Node(nodeBits uint8) (uint32, error) {
+	prefix, err := netip.ParsePrefix(s.CIDR)
+	if err != nil {
+		return 0, err
+	}
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > int(nodeBits) {
+		return 0, fmt.Errorf("%w: %s has %d host bits, node has %d", ErrSubnetTooLarge, s.CIDR, hostBits, nodeBits)
+	}
+
+	var addrs []net.Addr
+	if s.Interface != "" {
+		addrs, err = interfaceAddrs(s.Interface)
+	} else {
+		addrs, err = netInterfaceAddrs()
+	}
+	if err != nil {
+		return 0, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok {
+			continue
+		}
+		ip = ip.Unmap()
+		if !prefix.Contains(ip) {
+			continue
+		}
+		b := ip.As16()
+		return uint32(binary.BigEndian.Uint64(b[8:]) & (1<<hostBits - 1)), nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrNoSubnetAddress, s.CIDR)
+}
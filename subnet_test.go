@@ -0,0 +1,82 @@
+package goid
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func ipNet(cidr string) *net.IPNet {
+	ip, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	n.IP = ip
+	return n
+}
+
+func TestSubnetNode(t *testing.T) {
+	defer func() {
+		netInterfaceAddrs = net.InterfaceAddrs
+	}()
+	netInterfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{
+			ipNet("127.0.0.1/8"),
+			ipNet("10.1.6.9/22"),
+			ipNet("2001:db8::1:2a/64"),
+		}, nil
+	}
+
+	tests := []struct {
+		cidr     string
+		nodeBits uint8
+		want     uint32
+		err      error
+	}{
+		{"10.1.4.0/22", 10, 0x209, nil},
+		{"10.1.4.0/22", 12, 0x209, nil},
+		{"10.1.6.0/24", 8, 9, nil},
+		{"10.1.4.0/22", 9, 0, ErrSubnetTooLarge},
+		{"10.2.0.0/22", 10, 0, ErrNoSubnetAddress},
+		{"2001:db8::1:0/112", 16, 0x2a, nil},
+		{"2001:db8::/104", 24, 0x1002a, nil},
+		{"2001:db8::/64", 20, 0, ErrSubnetTooLarge},
+	}
+	for _, tt := range tests {
+		got, err := SubnetNode{CIDR: tt.cidr}.Node(tt.nodeBits)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("SubnetNode{%s}.Node(%d) error = %v, want %v", tt.cidr, tt.nodeBits, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SubnetNode{%s}.Node(%d) = %#x, want %#x", tt.cidr, tt.nodeBits, got, tt.want)
+		}
+	}
+
+	if _, err := (SubnetNode{CIDR: "10.1.4.0"}).Node(10); err == nil {
+		t.Error("expected an error for an invalid CIDR")
+	}
+}
+
+func TestSubnetNode_Interface(t *testing.T) {
+	defer func(f func(string) ([]net.Addr, error)) {
+		interfaceAddrs = f
+	}(interfaceAddrs)
+	interfaceAddrs = func(name string) ([]net.Addr, error) {
+		if name != "eth1" {
+			return nil, errors.New("no such interface")
+		}
+		return []net.Addr{ipNet("192.168.3.77/24")}, nil
+	}
+
+	id := NewID()
+	if err := SetNodeFrom(id, SubnetNode{CIDR: "192.168.3.0/24", Interface: "eth1"}, 8); err != nil {
+		t.Fatal(err)
+	}
+	if node, _ := id.GetNode(); node != 77 {
+		t.Errorf("GetNode() = %d, want 77", node)
+	}
+	if _, err := (SubnetNode{CIDR: "192.168.3.0/24", Interface: "eth0"}).Node(8); err == nil {
+		t.Error("expected an error for an unknown interface")
+	}
+}
@@ -0,0 +1,139 @@
+package goid
+
+import "fmt"
+
+// Tagger gives the IDs of a generator an entity tag, as TagSet.NewGenerator
+// does.
+type Tagger interface {
+	SetTag(tag uint32, tagBits uint8)
+	GetTag() (tag uint32, tagBits uint8)
+	Tag(id int64) uint32
+}
+
+var (
+	_ Tagger = (*ID)(nil)
+	_ Tagger = (*ID2)(nil)
+	_ Tagger = (*ID3)(nil)
+)
+
+func checkTag(tag uint32, tagBits uint8, seqBits int, delta, randomDelta uint32) {
+	if tagBits > 16 || seqBits < 2 ||
+		tag > (1<<tagBits-1) ||
+		delta >= (1<<seqBits-1) ||
+		randomDelta >= (1<<seqBits-1) {
+		panic("tag or tagBits is invalid")
+	}
+}
+
+// SetTag puts tag, e.g. the entity type, into a tag segment of tagBits taken
+// from the sequence; 0 tagBits removes the tag segment.
+func (i *ID) SetTag(tag uint32, tagBits uint8) {
+	checkTag(tag, tagBits, 21-int(tagBits)-int(i.shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.tag, i.tagBits = tag, tagBits
+}
+
+func (i *ID) GetTag() (tag uint32, tagBits uint8) {
+	return i.tag, i.tagBits
+}
+
+// Tag extracts the tag of id.
+func (i *ID) Tag(id int64) uint32 {
+	return i.Decompose(id).Tag
+}
+
+func (i *ID) seqBits() uint8 {
+	return 21 - i.tagBits - i.shardBits - i.nodeBits
+}
+
+func (i *ID2) SetTag(tag uint32, tagBits uint8) {
+	checkTag(tag, tagBits, 20-int(tagBits)-int(i.shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.tag, i.tagBits = tag, tagBits
+}
+
+func (i *ID2) GetTag() (tag uint32, tagBits uint8) {
+	return i.tag, i.tagBits
+}
+
+func (i *ID2) Tag(id int64) uint32 {
+	return i.Decompose(id).Tag
+}
+
+func (i *ID2) seqBits() uint8 {
+	return 20 - i.tagBits - i.shardBits - i.nodeBits
+}
+
+func (i *ID3) SetTag(tag uint32, tagBits uint8) {
+	checkTag(tag, tagBits, int(MaxBits)-int(i.bits)-int(tagBits)-int(i.shardBits)-int(i.nodeBits), i.delta, i.randomDelta)
+	i.tag, i.tagBits = tag, tagBits
+}
+
+func (i *ID3) GetTag() (tag uint32, tagBits uint8) {
+	return i.tag, i.tagBits
+}
+
+func (i *ID3) Tag(id int64) uint32 {
+	return i.Decompose(id).Tag
+}
+
+func (i *ID3) seqBits() uint8 {
+	return MaxBits - i.bits - i.tagBits - i.shardBits - i.nodeBits
+}
+
+// TagSet names the tags of a family of generators, one per entity type, that
+// share the same tag width; the tag of names[n] is n.
+type TagSet struct {
+	bits  uint8
+	names []string
+	tags  map[string]uint32
+}
+
+func NewTagSet(tagBits uint8, names ...string) *TagSet {
+	if tagBits < 1 || tagBits > 16 || len(names) > 1<<tagBits {
+		panic("tagBits is invalid")
+	}
+	s := &TagSet{bits: tagBits, names: names, tags: make(map[string]uint32, len(names))}
+	for n, name := range names {
+		if _, ok := s.tags[name]; ok {
+			panic("duplicate tag name " + name)
+		}
+		s.tags[name] = uint32(n)
+	}
+	return s
+}
+
+func (s *TagSet) Bits() uint8 {
+	return s.bits
+}
+
+func (s *TagSet) Tag(name string) (uint32, bool) {
+	tag, ok := s.tags[name]
+	return tag, ok
+}
+
+// Name returns the name of tag, or "" if it has none.
+func (s *TagSet) Name(tag uint32) string {
+	if tag >= uint32(len(s.names)) {
+		return ""
+	}
+	return s.names[tag]
+}
+
+// NameOf returns the name of the tag of id under layout l.
+func (s *TagSet) NameOf(l Layout, id int64) string {
+	return s.Name(l.decompose(id).Tag)
+}
+
+// NewGenerator creates a generator of the named layout (see NewGenerator)
+// tagged for the entity type name.
+func (s *TagSet) NewGenerator(layout, name string) (Generator, error) {
+	tag, ok := s.tags[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tag %q", name)
+	}
+	g, err := NewGenerator(layout)
+	if err != nil {
+		return nil, err
+	}
+	g.(Tagger).SetTag(tag, s.bits)
+	return g, nil
+}
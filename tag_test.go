@@ -0,0 +1,148 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetTag(t *testing.T) {
+	for _, g := range []interface {
+		Generator
+		Validator
+		Tagger
+	}{NewID(), NewID2(), NewID3()} {
+		g.SetNode(5, 4)
+		g.SetTag(3, 3)
+		if tag, tagBits := g.GetTag(); tag != 3 || tagBits != 3 {
+			t.Errorf("%T.GetTag() = %d, %d", g, tag, tagBits)
+		}
+		l := g.Layout()
+		if l.TimeBits+l.TagBits+l.NodeBits+l.SeqBits != MaxBits {
+			t.Errorf("%T.Layout() = %+v does not fill %d bits", g, l, MaxBits)
+		}
+
+		var last int64
+		for n := 0; n < 1000; n++ {
+			id := g.Generate()
+			if id <= last || id >= 1<<MaxBits {
+				t.Fatalf("%T.Generate() = %d after %d", g, id, last)
+			}
+			last = id
+			p := g.Decompose(id)
+			if p.Tag != 3 || p.Node != 5 || p.Sequence == 0 {
+				t.Fatalf("%T.Decompose(%d) = %+v", g, id, p)
+			}
+			if d := time.Since(p.Time); d < 0 || d > 2*time.Second {
+				t.Fatalf("%T.Decompose(%d).Time = %v", g, id, p.Time)
+			}
+		}
+		if err := g.Validate(last); err != nil {
+			t.Errorf("%T.Validate() = %v", g, err)
+		}
+
+		other, _ := NewGenerator(l.Name)
+		other.SetNode(5, 4)
+		other.(Tagger).SetTag(2, 3)
+		if err := g.Validate(other.Generate()); !errors.Is(err, ErrIDTag) {
+			t.Errorf("%T.Validate() error = %v, want %v", g, err, ErrIDTag)
+		}
+
+		g.SetTag(0, 0)
+		if p := g.Decompose(g.Generate()); p.Tag != 0 || p.Node != 5 {
+			t.Errorf("%T.Decompose() without tag = %+v", g, p)
+		}
+	}
+}
+
+func TestSetTag_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     uint32
+		tagBits uint8
+	}{
+		{"tag out of range", 8, 3},
+		{"too wide", 1, 16},
+		{"no tag bits", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			g := NewID2()
+			g.SetNode(1, 4)
+			g.SetTag(tt.tag, tt.tagBits)
+		})
+	}
+
+	g := NewID()
+	g.SetTag(1, 4)
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for a node not fitting next to the tag")
+		}
+	}()
+	g.SetNode(1, 16)
+}
+
+func TestSetTag_RangeCompose(t *testing.T) {
+	g := NewID3()
+	g.SetNode(1, 4)
+	g.SetTag(2, 2)
+	now := time.Now()
+	id := g.Generate()
+	min, max := g.Range(now.Add(-time.Second), now.Add(time.Second))
+	if id < min || id > max {
+		t.Errorf("id %d outside of [%d, %d]", id, min, max)
+	}
+	if g.Tag(min) != 2 || g.Tag(max) != 2 {
+		t.Errorf("range bounds %d, %d do not carry the tag", min, max)
+	}
+
+	ms := time.UnixMilli(now.UnixMilli())
+	composed, err := g.Compose(ms, 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Decompose(composed); p.Tag != 2 || p.Node != 1 || p.Sequence != 7 || !p.Time.Equal(ms) {
+		t.Errorf("Decompose(Compose()) = %+v", p)
+	}
+}
+
+func TestTagSet(t *testing.T) {
+	tags := NewTagSet(2, "order", "user", "payment")
+	orders, err := tags.NewGenerator("id3", "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payments, err := tags.NewGenerator("id3", "payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tags.NewGenerator("id3", "refund"); err == nil {
+		t.Error("expected an error for an unknown tag")
+	}
+
+	if name := tags.NameOf(orders.Layout(), orders.Generate()); name != "order" {
+		t.Errorf("NameOf() = %q, want order", name)
+	}
+	if name := tags.NameOf(orders.Layout(), payments.Generate()); name != "payment" {
+		t.Errorf("NameOf() = %q, want payment", name)
+	}
+	if tag, ok := tags.Tag("user"); !ok || tag != 1 {
+		t.Errorf("Tag(user) = %d, %v", tag, ok)
+	}
+	if name := tags.Name(3); name != "" {
+		t.Errorf("Name(3) = %q, want empty", name)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for too many names")
+		}
+	}()
+	NewTagSet(1, "a", "b", "c")
+}
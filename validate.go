@@ -0,0 +1,122 @@
+package goid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultValidSkew = time.Minute
+)
+
+var (
+	ErrIDOutOfRange = errors.New("id is not a positive 53-bit integer")
+	ErrIDTimestamp  = errors.New("id timestamp is out of the valid window")
+	ErrIDTag        = errors.New("id tag does not match")
+	ErrIDNode       = errors.New("id node is not allowed")
+	ErrIDSequence   = errors.New("id sequence is not reachable")
+)
+
+// Validator checks IDs received from outside against the settings of a
+// generator.
+type Validator interface {
+	Validate(id int64) error
+}
+
+var (
+	_ Validator = (*ID)(nil)
+	_ Validator = (*ID2)(nil)
+	_ Validator = (*ID3)(nil)
+)
+
+// ValidationError wraps one of ErrIDOutOfRange, ErrIDTimestamp, ErrIDTag,
+// ErrIDNode and ErrIDSequence with the rejected ID.
+type ValidationError struct {
+	ID  int64
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid id %d: %v", e.ID, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+type validator struct {
+	validEpoch time.Time
+	validSkew  time.Duration
+	validNodes map[uint32]struct{}
+}
+
+// SetValidEpoch rejects IDs whose timestamp is before t, e.g. the date the
+// service first went live.
+func (v *validator) SetValidEpoch(t time.Time) {
+	v.validEpoch = t
+}
+
+func (v *validator) GetValidEpoch() time.Time {
+	return v.validEpoch
+}
+
+// SetValidSkew sets how far in the future an ID timestamp may be.
+func (v *validator) SetValidSkew(d time.Duration) {
+	if d < 0 {
+		panic("invalid validSkew")
+	}
+	v.validSkew = d
+}
+
+func (v *validator) GetValidSkew() time.Duration {
+	return v.validSkew
+}
+
+// SetAllowedNodes restricts the nodes Validate accepts; no nodes allows all.
+func (v *validator) SetAllowedNodes(nodes ...uint32) {
+	if len(nodes) == 0 {
+		v.validNodes = nil
+		return
+	}
+	v.validNodes = make(map[uint32]struct{}, len(nodes))
+	for _, n := range nodes {
+		v.validNodes[n] = struct{}{}
+	}
+}
+
+func (v *validator) validate(l Layout, id int64, now time.Time, delta, randomDelta uint32) error {
+	if id <= 0 || id >= 1<<MaxBits {
+		return &ValidationError{ID: id, Err: ErrIDOutOfRange}
+	}
+	p := l.decompose(id)
+	if p.Time.Before(v.validEpoch) || p.Time.After(now.Add(v.validSkew)) {
+		return &ValidationError{ID: id, Err: ErrIDTimestamp}
+	}
+	if p.Tag != l.Tag {
+		return &ValidationError{ID: id, Err: ErrIDTag}
+	}
+	if v.validNodes != nil {
+		if _, ok := v.validNodes[p.Node]; !ok {
+			return &ValidationError{ID: id, Err: ErrIDNode}
+		}
+	}
+	if p.Sequence == 0 || randomDelta == 0 && p.Sequence%delta != 0 {
+		return &ValidationError{ID: id, Err: ErrIDSequence}
+	}
+	return nil
+}
+
+// Validate reports whether id could have been generated with the current
+// layout, node and delta settings of i.
+func (i *ID) Validate(id int64) error {
+	return i.validate(i.Layout(), id, i.now(), i.delta, i.randomDelta)
+}
+
+func (i *ID2) Validate(id int64) error {
+	return i.validate(i.Layout(), id, i.now(), i.delta, i.randomDelta)
+}
+
+func (i *ID3) Validate(id int64) error {
+	return i.validate(i.Layout(), id, i.now(), i.delta, i.randomDelta)
+}
@@ -0,0 +1,78 @@
+package goid
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	for _, g := range []interface {
+		Generator
+		Validator
+	}{NewID(), NewID2(), NewID3()} {
+		g.SetNode(3, 4)
+		g.SetDelta(2)
+		for i := 0; i < 1000; i++ {
+			if err := g.Validate(g.Generate()); err != nil {
+				t.Fatalf("%T.Validate() error = %v", g, err)
+			}
+		}
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	id := NewID()
+	id.SetNode(3, 4)
+	id.SetDelta(2)
+	now := time.Now().Unix()
+	compose := func(ts int64, node, seq uint32) int64 {
+		return ts<<21 | int64(node)<<17 | int64(seq)
+	}
+
+	tests := []struct {
+		name    string
+		id      int64
+		setup   func()
+		wantErr error
+	}{
+		{"valid", compose(now, 3, 4), nil, nil},
+		{"negative", -compose(now, 3, 4), nil, ErrIDOutOfRange},
+		{"zero", 0, nil, ErrIDOutOfRange},
+		{"too large", 1 << MaxBits, nil, ErrIDOutOfRange},
+		{"future", compose(now+3600, 3, 4), nil, ErrIDTimestamp},
+		{"within skew", compose(now+30, 3, 4), nil, nil},
+		{"before epoch", compose(now-3600, 3, 4), func() { id.SetValidEpoch(time.Now().Add(-time.Minute)) }, ErrIDTimestamp},
+		{"node not allowed", compose(now, 5, 4), func() { id.SetAllowedNodes(1, 2, 3) }, ErrIDNode},
+		{"node allowed", compose(now, 2, 4), func() { id.SetAllowedNodes(1, 2, 3) }, nil},
+		{"zero sequence", compose(now, 3, 0), nil, ErrIDSequence},
+		{"unreachable sequence", compose(now, 3, 5), nil, ErrIDSequence},
+		{"random delta", compose(now, 3, 5), func() { id.SetRandomDelta(10) }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id.SetValidEpoch(time.Time{})
+			id.SetAllowedNodes()
+			id.randomDelta = 0
+			if tt.setup != nil {
+				tt.setup()
+			}
+			err := id.Validate(tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+			var ve *ValidationError
+			if err != nil && (!errors.As(err, &ve) || ve.ID != tt.id) {
+				t.Errorf("Validate() error %v is not a *ValidationError for %d", err, tt.id)
+			}
+		})
+	}
+}
+
+func TestValidate_Skew(t *testing.T) {
+	id := NewID3()
+	id.SetValidSkew(0)
+	if err := id.Validate((time.Now().UnixMilli() + 1000) << 11); !errors.Is(err, ErrIDTimestamp) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrIDTimestamp)
+	}
+}